@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"html/template"
@@ -8,77 +9,98 @@ import (
 	"io/fs"
 	"log/slog"
 	"net/http"
-	"regexp"
-	"slices"
-	"sort"
+	"net/url"
 	"strings"
 	"time"
 
 	"capytal.cc/assets"
-	"capytal.cc/internals/natsort"
+	"capytal.cc/internals/httpcache"
+	"capytal.cc/internals/posts"
+	"capytal.cc/internals/remote"
+	"capytal.cc/internals/seo"
+	"capytal.cc/internals/trace"
+	"capytal.cc/locales"
 	"capytal.cc/templates"
 	"capytal.cc/tinyssert"
-	"forge.capytal.company/loreddev/blogo"
 	"forge.capytal.company/loreddev/blogo/plugin"
-	"forge.capytal.company/loreddev/blogo/plugins"
-	"forge.capytal.company/loreddev/blogo/plugins/gitea"
 	"forge.capytal.company/loreddev/x/smalltrip"
-	"forge.capytal.company/loreddev/x/smalltrip/exception"
 	"forge.capytal.company/loreddev/x/smalltrip/middleware"
 	chromahtml "github.com/alecthomas/chroma/v2/formatters/html"
+	"github.com/alecthomas/chroma/v2/styles"
 	links "github.com/fundipper/goldmark-links"
-	"github.com/goodsign/monday"
 	"github.com/yuin/goldmark"
 	highlighting "github.com/yuin/goldmark-highlighting/v2"
 	meta "github.com/yuin/goldmark-meta"
-	"github.com/yuin/goldmark/ast"
 	"github.com/yuin/goldmark/extension"
 	"github.com/yuin/goldmark/parser"
-	"github.com/yuin/goldmark/renderer"
-	"github.com/yuin/goldmark/text"
 	callout "gitlab.com/staticnoise/goldmark-callout"
 	"go.abhg.dev/goldmark/anchor"
 )
 
-var md = goldmark.New(
-	goldmark.WithParserOptions(
-		parser.WithAutoHeadingID(),
-	),
-	goldmark.WithExtensions(
-		extension.Footnote,
-		extension.GFM,
-		extension.DefinitionList,
-		extension.Typographer,
-		highlighting.NewHighlighting(
-			highlighting.WithStyle("monokai"),
-			highlighting.WithFormatOptions(
-				chromahtml.WithLineNumbers(true),
-			),
+// DefaultTrustedDomains are the domains links to which are not marked as
+// external in rendered markdown.
+var DefaultTrustedDomains = []string{
+	"capytal.cc",
+	"capytal.company",
+	"forge.capytal.company",
+	"lored.dev",
+}
+
+// DefaultHighlightStyle is the Chroma style of the code blocks of rendered markdown.
+const DefaultHighlightStyle = "monokai"
+
+// newMarkdown creates the markdown parser and renderer of blog posts and pages.
+// Links to domains other than the trusted ones open in a new tab and are not
+// followed by search engines.
+func newMarkdown(trusted []string, style string) goldmark.Markdown {
+	domains := make(map[string]bool, len(trusted))
+	for _, d := range trusted {
+		domains[d] = true
+	}
+
+	return goldmark.New(
+		goldmark.WithParserOptions(
+			parser.WithAutoHeadingID(),
 		),
-		meta.New(meta.WithStoresInDocument()),
-		&anchor.Extender{},
-		links.NewExtender(
-			map[string]bool{
-				"capytal.cc":            true,
-				"capytal.company":       true,
-				"forge.capytal.company": true,
-				"lored.dev":             true,
-			},
-			map[string]string{
-				"rel":    "nofollow noopener noreferrer",
-				"target": "_blank",
-			},
+		goldmark.WithExtensions(
+			extension.Footnote,
+			extension.GFM,
+			extension.DefinitionList,
+			extension.Typographer,
+			highlighting.NewHighlighting(
+				highlighting.WithStyle(style),
+				highlighting.WithFormatOptions(
+					chromahtml.WithLineNumbers(true),
+				),
+			),
+			meta.New(meta.WithStoresInDocument()),
+			&anchor.Extender{},
+			links.NewExtender(
+				domains,
+				map[string]string{
+					"rel":    "nofollow noopener noreferrer",
+					"target": "_blank",
+				},
+			),
+			callout.CalloutExtention,
 		),
-		callout.CalloutExtention,
-	),
-)
+	)
+}
 
 func NewApp(opts ...Option) (http.Handler, error) {
 	app := &app{
 		assets:    assets.Files(),
 		templates: templates.Templates(),
 
+		baseURL:     "https://capytal.cc",
+		blogConfigs: DefaultBlogs,
+		pageConfigs: DefaultPages,
+
+		trustedDomains: DefaultTrustedDomains,
+		highlightStyle: DefaultHighlightStyle,
+
 		cache:  true,
+		tracer: trace.NewTracer(nil),
 		log:    slog.New(slog.DiscardHandler),
 		assert: tinyssert.NewDisabledAssertions(),
 	}
@@ -87,6 +109,50 @@ func NewApp(opts ...Option) (http.Handler, error) {
 		opt(app)
 	}
 
+	if len(app.blogConfigs) == 0 {
+		return nil, errors.New("at least one blog must be configured")
+	}
+
+	paths := map[string]bool{}
+	for _, c := range app.pageConfigs {
+		if err := c.validatePath(); err != nil {
+			return nil, err
+		}
+		if paths[c.Path] {
+			return nil, fmt.Errorf("page %q is configured more than once", c.Path)
+		}
+		paths[c.Path] = true
+
+		if err := c.validate(); err != nil {
+			return nil, err
+		}
+		if !c.remote() && app.pagesSource == nil {
+			return nil, fmt.Errorf("page %q has a local source, but no pages file system is set", c.Path)
+		}
+	}
+
+	if _, ok := styles.Registry[app.highlightStyle]; !ok {
+		return nil, fmt.Errorf("unknown highlight style %q", app.highlightStyle)
+	}
+	app.markdown = newMarkdown(app.trustedDomains, app.highlightStyle)
+
+	app.metrics = newAppMetrics()
+	app.templates = app.metrics.templates(app.templates)
+
+	if app.cache && app.responses == nil {
+		app.responses = httpcache.New(httpcache.WithLogger(app.log.WithGroup("httpcache")))
+	}
+	if app.responses != nil {
+		app.metrics.registerCache(app.responses)
+	}
+
+	if app.remote == nil {
+		app.remote = remote.New(
+			remote.WithClient(&http.Client{Transport: app.metrics.transport(tracingTransport(http.DefaultTransport))}),
+			remote.WithLogger(app.log.WithGroup("remote")),
+		)
+	}
+
 	app.setup()
 
 	return app, nil
@@ -102,6 +168,115 @@ func WithTemplates(t templates.ITemplate) Option {
 	return func(a *app) { a.templates = t }
 }
 
+func WithBaseURL(url string) Option {
+	return func(a *app) { a.baseURL = strings.TrimSuffix(url, "/") }
+}
+
+// WithBlogs sets the blogs served by the application, one for each language. The
+// first blog is used for requests without a supported language.
+func WithBlogs(blogs ...BlogConfig) Option {
+	return func(a *app) { a.blogConfigs = blogs }
+}
+
+// WithBlogSource makes the blog of the language read its posts from fsys instead
+// of the forge repository.
+func WithBlogSource(lang string, fsys fs.FS) Option {
+	return func(a *app) {
+		if a.blogSources == nil {
+			a.blogSources = map[string]fs.FS{}
+		}
+		a.blogSources[lang] = fsys
+	}
+}
+
+// WithPages sets the markdown pages served by the application, replacing the
+// default ones.
+func WithPages(pages ...PageConfig) Option {
+	return func(a *app) { a.pageConfigs = pages }
+}
+
+// WithPagesSource sets the file system local markdown pages are read from.
+func WithPagesSource(fsys fs.FS) Option {
+	return func(a *app) { a.pagesSource = fsys }
+}
+
+// WithWellKnownFile sets the content of a file served at the root of the site
+// or under "/.well-known/", such as "robots.txt" or "security.txt", overriding
+// the files in the assets and the generated defaults.
+func WithWellKnownFile(name, content string) Option {
+	return func(a *app) {
+		if a.wellKnownFiles == nil {
+			a.wellKnownFiles = map[string]string{}
+		}
+		a.wellKnownFiles[name] = content
+	}
+}
+
+// WithPreviewSecret sets the key used to sign preview tokens, which allow drafts
+// and scheduled posts to be viewed before being published. Previews are disabled
+// without a secret.
+func WithPreviewSecret(secret []byte) Option {
+	return func(a *app) { a.previewSecret = secret }
+}
+
+// WithWebhookSecret sets the secret used to verify the signature of the forge's
+// webhooks at "/hooks/gitea". The endpoint is disabled without a secret.
+func WithWebhookSecret(secret []byte) Option {
+	return func(a *app) { a.webhookSecret = secret }
+}
+
+// WithResponseCache sets the cache of rendered pages. Defaults to a cache with
+// the default options of [httpcache.New], unless caching is disabled.
+func WithResponseCache(c *httpcache.Cache) Option {
+	return func(a *app) { a.responses = c }
+}
+
+// WithAdminToken sets the bearer token required by the administration endpoints,
+// "/admin/cache/purge" and "/metrics". The endpoints are disabled without a token.
+func WithAdminToken(token string) Option {
+	return func(a *app) { a.adminToken = token }
+}
+
+// WithSecurityExpires fixes the expiration date of "/.well-known/security.txt",
+// which otherwise is a year from the day it is served.
+func WithSecurityExpires(t time.Time) Option {
+	return func(a *app) { a.securityExpires = t.UTC() }
+}
+
+// WithSourceDates makes the dates of the served content depend only on the
+// content of its sources, so the same sources are always served the same, as
+// needed by static exports. Posts without a date in their front matter are left
+// undated, and "/.well-known/security.txt" expires a year after the newest post,
+// unless fixed by [WithSecurityExpires].
+func WithSourceDates() Option {
+	return func(a *app) { a.sourceDates = true }
+}
+
+// WithTrustedDomains sets the domains links to which are not marked as external
+// in rendered markdown. Defaults to [DefaultTrustedDomains].
+func WithTrustedDomains(domains ...string) Option {
+	return func(a *app) { a.trustedDomains = domains }
+}
+
+// WithHighlightStyle sets the Chroma style of the code blocks of rendered
+// markdown. Defaults to [DefaultHighlightStyle].
+func WithHighlightStyle(style string) Option {
+	return func(a *app) { a.highlightStyle = style }
+}
+
+// WithSpanExporter sets the exporter of the spans traced for each request and
+// the work it causes. Without one, spans are discarded, but trace contexts are
+// still propagated to upstream servers.
+func WithSpanExporter(e trace.Exporter) Option {
+	return func(a *app) { a.tracer = trace.NewTracer(e) }
+}
+
+// WithDevelopment marks the application as running in a development environment,
+// so it is not indexed by search engines.
+func WithDevelopment() Option {
+	return func(a *app) { a.dev = true }
+}
+
 func WithCacheDisabled() Option {
 	return func(a *app) { a.cache = false }
 }
@@ -110,6 +285,12 @@ func WithLogger(logger *slog.Logger) Option {
 	return func(a *app) { a.log = logger }
 }
 
+// WithRemoteFetcher sets the fetcher used to get remote documents, such as the
+// sources of remote markdown pages.
+func WithRemoteFetcher(f *remote.Fetcher) Option {
+	return func(a *app) { a.remote = f }
+}
+
 func WithAssertions(assertions tinyssert.Assertions) Option {
 	return func(a *app) { a.assert = assertions }
 }
@@ -120,6 +301,32 @@ type app struct {
 	assets    fs.FS
 	templates templates.ITemplate
 
+	baseURL     string
+	blogConfigs []BlogConfig
+	blogSources map[string]fs.FS
+	blogs       []*blog
+	pageConfigs []PageConfig
+	pagesSource fs.FS
+
+	sitemapDoc sitemapCache
+
+	markdown       goldmark.Markdown
+	trustedDomains []string
+	highlightStyle string
+
+	wellKnownFiles  map[string]string
+	securityExpires time.Time
+	sourceDates     bool
+	previewSecret   []byte
+	webhookSecret   []byte
+
+	remote     *remote.Fetcher
+	metrics    *appMetrics
+	responses  *httpcache.Cache
+	adminToken string
+	tracer     *trace.Tracer
+
+	dev    bool
 	cache  bool
 	log    *slog.Logger
 	assert tinyssert.Assertions
@@ -133,7 +340,9 @@ func (app *app) setup() {
 		smalltrip.WithLogger(app.log.WithGroup("smalltrip")),
 	)
 
-	router.Use(middleware.Logger(app.log.WithGroup("requests")))
+	handle := func(pattern string, h http.Handler) {
+		router.Handle(pattern, app.traced(pattern, app.metrics.instrument(pattern, h)))
+	}
 
 	if app.cache {
 		router.Use(middleware.Cache())
@@ -141,173 +350,145 @@ func (app *app) setup() {
 		router.Use(middleware.DisableCache())
 	}
 
-	router.Handle("/assets/", http.StripPrefix("/assets/", http.FileServerFS(app.assets)))
+	handle("/assets/", http.StripPrefix("/assets/", http.FileServerFS(app.assets)))
 
-	router.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
-		if r.URL.Path != "/" {
-			http.Redirect(w, r, "/", http.StatusSeeOther)
-		}
-		if r.URL.Query().Get("lang") == "" {
-			langRedirect(w, r)
-		}
+	homepage := langRedirect(app.cached(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lang := r.URL.Query().Get("lang")
 
-		err := app.templates.ExecuteTemplate(w, "homepage", map[string]any{
-			"Lang": r.URL.Query().Get("lang"),
-		})
-		if err != nil {
-			exception.InternalServerError(err).ServeHTTP(w, r)
-			return
-		}
-	})
-	router.HandleFunc("/about/", func(w http.ResponseWriter, r *http.Request) {
-		if r.URL.Query().Get("lang") == "" {
-			langRedirect(w, r)
-		}
+		meta := app.pageMeta("/", lang, "Capytal")
+		meta.Description = locales.Registry().T(lang, "homepage.description")
 
-		err := app.templates.ExecuteTemplate(w, "about", map[string]any{
-			"Lang": r.URL.Query().Get("lang"),
+		err := app.templates.ExecuteTemplate(w, "homepage", map[string]any{
+			"Lang": lang,
+			"Meta": meta,
 		})
 		if err != nil {
-			exception.InternalServerError(err).ServeHTTP(w, r)
+			app.serverError(w, r, err)
 			return
 		}
-	})
-	router.HandleFunc("/privacy/", func(w http.ResponseWriter, r *http.Request) {
-		if r.URL.Query().Get("lang") == "" {
-			langRedirect(w, r)
-		}
-
-		lang := ""
-		if l := r.URL.Query().Get("lang"); l != "" && !strings.Contains(l, "en") {
-			lang = fmt.Sprintf("_%s", l)
-		}
-
-		res, err := http.Get(fmt.Sprintf("https://forge.capytal.company/api/v1/repos/capytal/privacy-policy/raw/PRIVACY_POLICY%s.md", lang))
-		if err != nil {
-			exception.InternalServerError(err).ServeHTTP(w, r)
-			return
-		}
-
-		c, err := io.ReadAll(res.Body)
-		if err != nil {
-			exception.InternalServerError(err).ServeHTTP(w, r)
+	})))
+	// The root route matches every path not matched by other routes.
+	handle("/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			app.notFound(w, r)
 			return
 		}
-
-		doc := md.Parser().Parse(text.NewReader(c))
-		meta := doc.OwnerDocument().Meta()
-
-		title := "Privacy Policy"
-		if t, ok := meta["title"]; ok {
-			tt, ok := t.(string)
-			if ok {
-				title = tt
+		homepage.ServeHTTP(w, r)
+	}))
+	// A markdown page may replace the built-in about page.
+	if !app.hasPage("/about/") {
+		handle("/about/", langRedirect(app.cached(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			lang := r.URL.Query().Get("lang")
+
+			meta := app.pageMeta("/about/", lang, locales.Registry().T(lang, "about.title"))
+			meta.Description = locales.Registry().T(lang, "about.description")
+
+			err := app.templates.ExecuteTemplate(w, "about", map[string]any{
+				"Lang": lang,
+				"Meta": meta,
+			})
+			if err != nil {
+				app.serverError(w, r, err)
+				return
 			}
-		}
-
-		changeDate, err := time.Parse(time.DateOnly, "2025-04-11")
-		app.assert.Nil(err, "This date should always be valid")
+		}))))
+	}
+	for _, c := range app.pageConfigs {
+		handle(c.Path, langRedirect(app.cached(app.page(c))))
+	}
 
-		if d, ok := meta["modified"]; ok {
-			if s, ok := d.(string); ok {
-				t, err := time.Parse(time.RFC3339, s)
+	blogs := make([]*blog, len(app.blogConfigs))
+	for i, c := range app.blogConfigs {
+		blogs[i] = app.newBlog(c)
+	}
+	app.blogs = blogs
+
+	handle("/metrics", app.metricsHandler())
+	handle("/healthz", app.healthz())
+	handle("/readyz", app.readyz())
+	handle("/hooks/gitea", app.giteaHook())
+	handle("/admin/cache/purge", app.purgeCache())
+	handle("/sitemap.xml", app.sitemap())
+	handle("/robots.txt", app.wellKnown("robots.txt"))
+	handle("/humans.txt", app.wellKnown("humans.txt"))
+	handle("/.well-known/", app.wellKnownDirectory())
+
+	handle("/blog/", langRedirect(app.cached(http.StripPrefix("/blog/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		blog := app.blog(r.URL.Query().Get("lang"))
+
+		// Pages of the blog link to posts in other languages, so any blog
+		// changing may change them.
+		w.Header().Set(httpcache.TagHeader, "blog blog:"+blog.lang)
+
+		switch p := r.URL.Path; {
+		case p == "feed.xml" || p == "atom.xml" || p == "feed.json":
+			app.feed(blog).ServeHTTP(w, r)
+		case p == "search":
+			app.search(blog).ServeHTTP(w, r)
+		case isTaxonomyPath(p):
+			app.taxonomy(blog).ServeHTTP(w, r)
+		default:
+			hidden, err := blog.Hidden(r.Context(), p)
+			if err != nil {
+				app.serverError(w, r, err)
+				return
+			}
+			if hidden {
+				if !verifyPreview(app.previewSecret, blog.lang, p, r.URL.Query().Get("preview"), time.Now()) {
+					app.notFound(w, r)
+					return
+				}
+				r = r.WithContext(withPreview(r.Context()))
+				w.Header().Set("X-Robots-Tag", "noindex")
+				w.Header().Set("Cache-Control", "private, no-store")
+			} else if p != "" {
+				post, err := blog.Post(r.Context(), p)
 				if err != nil {
-					exception.InternalServerError(err).ServeHTTP(w, r)
+					app.serverError(w, r, err)
 					return
 				}
-				changeDate = t
+				if post == nil {
+					// The post may exist only in other languages, or with another
+					// name in this one.
+					redirect, from, fallback, err := app.translationFallback(r.Context(), blog, p)
+					if err != nil {
+						app.serverError(w, r, err)
+						return
+					}
+					if redirect != "" {
+						http.Redirect(w, r, redirect, http.StatusFound)
+						return
+					}
+					if fallback != nil {
+						w.Header().Set("Content-Language", from.lang)
+						err := from.renderer.RenderPost(&contextWriter{w, r.Context()}, fallback, blog.lang)
+						if err != nil {
+							app.serverError(w, r, err)
+						}
+						return
+					}
+
+					if blog.Gone(p) {
+						app.fail(w, r, http.StatusGone, nil)
+						return
+					}
+					if ok, err := blog.Exists(r.Context(), p); err != nil {
+						app.serverError(w, r, err)
+						return
+					} else if !ok {
+						app.notFound(w, r)
+						return
+					}
+				}
 			}
-		}
-
-		f := new(strings.Builder)
-		err = md.Renderer().Render(f, c, doc)
-		if err != nil {
-			exception.InternalServerError(err).ServeHTTP(w, r)
-			return
-		}
-
-		locale := r.URL.Query().Get("lang")
-		if locale == "" {
-			locale = "en-US"
-		}
-		locale = strings.Replace(locale, "-", "_", 1)
 
-		format, ok := monday.LongFormatsByLocale[monday.Locale(locale)]
-		if !ok {
-			format = time.DateTime
+			blog.ServeHTTP(&contextWriter{w, r.Context()}, r)
 		}
-
-		err = app.templates.ExecuteTemplate(w, "privacy-policy", map[string]any{
-			"Title":      title,
-			"Lang":       r.URL.Query().Get("lang"),
-			"Content":    template.HTML(f.String()),
-			"ChangeDate": monday.Format(changeDate, format, monday.Locale(locale)),
-		})
-		if err != nil {
-			exception.InternalServerError(err).ServeHTTP(w, r)
-			return
-		}
-	})
-
-	blogEN := app.blogEN()
-	blogPT := app.blogPT()
-	router.Handle("/blog/", http.StripPrefix("/blog/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if r.URL.Query().Get("lang") == "" {
-			langRedirect(w, r)
-		}
-
-		switch r.URL.Query().Get("lang") {
-		case "pt-BR":
-			blogPT.ServeHTTP(w, r)
-		default:
-			blogEN.ServeHTTP(w, r)
-		}
-	})))
+	})))))
 
 	app.router = router
 }
 
-func langRedirect(w http.ResponseWriter, r *http.Request) {
-	acceptedLang := r.Header.Get("Accept-Language")
-	if strings.Contains(acceptedLang, "pt") {
-		http.Redirect(w, r, fmt.Sprintf("%s?lang=pt-BR", r.URL.Path), http.StatusSeeOther)
-	}
-}
-
-func (app *app) blogEN() blogo.Blogo {
-	blog := blogo.New(blogo.Opts{
-		Assertions: app.assert,
-		Logger:     app.log.WithGroup("blogo"),
-	})
-
-	gitea := gitea.New("capytal", "capytal.cc-blog", "https://forge.capytal.company")
-	blog.Use(gitea)
-
-	blog.Use(&listRenderer{app.templates, "en-US"})
-	blog.Use(NewBlogPostRenderer(app.templates, "en-US"))
-	blog.Use(plugins.NewPlainText())
-
-	return blog
-}
-
-func (app *app) blogPT() blogo.Blogo {
-	blog := blogo.New(blogo.Opts{
-		Assertions: app.assert,
-		Logger:     app.log.WithGroup("blogo-pt"),
-	})
-
-	gitea := gitea.New("capytal", "capytal.cc-blog", "https://forge.capytal.company", gitea.Opts{
-		Ref: "main-pt",
-	})
-	blog.Use(gitea)
-
-	blog.Use(&listRenderer{app.templates, "pt-BR"})
-	blog.Use(NewBlogPostRenderer(app.templates, "pt-BR"))
-	blog.Use(plugins.NewPlainText())
-
-	return blog
-}
-
 func (app *app) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	app.assert.NotNil(w)
 	app.assert.NotNil(r)
@@ -318,20 +499,29 @@ func (app *app) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 type blogPostRenderer struct {
 	templates templates.ITemplate
-	lang      string
+	blog      *blog
+	meta      func(path, lang, title string) seo.Page
 
-	parser   parser.Parser
-	renderer renderer.Renderer
+	translations func(ctx context.Context, p *posts.Post) ([]translation, error)
+
+	markdown goldmark.Markdown
 }
 
 var _ plugin.Renderer = (*blogPostRenderer)(nil)
 
-func NewBlogPostRenderer(templates templates.ITemplate, lang string) *blogPostRenderer {
+func NewBlogPostRenderer(
+	templates templates.ITemplate,
+	blog *blog,
+	meta func(path, lang, title string) seo.Page,
+	translations func(ctx context.Context, p *posts.Post) ([]translation, error),
+	markdown goldmark.Markdown,
+) *blogPostRenderer {
 	return &blogPostRenderer{
-		templates: templates,
-		lang:      lang,
-		parser:    md.Parser(),
-		renderer:  md.Renderer(),
+		templates:    templates,
+		blog:         blog,
+		meta:         meta,
+		translations: translations,
+		markdown:     markdown,
 	}
 }
 
@@ -339,58 +529,129 @@ func (r *blogPostRenderer) Name() string {
 	return "capytal-blogpostrenderer-renderer"
 }
 
-var re = regexp.MustCompile(`<h1>(.*?)</h1>`)
-
 func (r *blogPostRenderer) Render(src fs.File, w io.Writer) error {
-	c, err := io.ReadAll(src)
+	info, err := src.Stat()
 	if err != nil {
 		return err
 	}
 
-	doc := r.parser.Parse(text.NewReader(c))
-	meta := doc.OwnerDocument().Meta()
+	// The post is rendered as loaded in the blog's index, so its dates and whether
+	// it is published are the same as in the lists and feeds.
+	ctx := writerContext(w)
+	post, err := r.blog.Loaded(ctx, info.Name())
+	if err != nil {
+		return err
+	}
+	if post == nil {
+		return fmt.Errorf("file %q is not a post", info.Name())
+	}
+	if !post.IsPublished(time.Now()) && !isPreview(ctx) {
+		return fmt.Errorf("post %q is not published", post.Name)
+	}
 
-	title := "Blog"
-	if t, ok := meta["title"]; ok {
-		tt, ok := t.(string)
-		if ok {
-			title = tt
-		}
-	} else {
-		err := ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
-			if n.Kind().String() != "Heading" {
-				return ast.WalkContinue, nil
-			}
+	return r.RenderPost(w, post, "")
+}
 
-			if h, ok := n.(*ast.Heading); !ok || h.Level > 1 {
-				return ast.WalkContinue, nil
-			}
+// RenderPost renders the post of the renderer's blog. If the post is shown in
+// place of a missing translation, fallback is the language originally requested,
+// used to show a notice to the reader.
+func (r *blogPostRenderer) RenderPost(w io.Writer, post *posts.Post, fallback string) error {
+	name := post.Name
 
-			// TODO: This is deprecated
-			title = string(n.Text(c))
-			return ast.WalkStop, nil
-		})
-		if err != nil {
-			return err
-		}
+	ctx, span := trace.Start(writerContext(w), "blog.render")
+	defer span.Finish()
+	span.SetAttr("lang", r.blog.lang)
+	span.SetAttr("post", name)
+
+	log := r.blog.log
+	if l := trace.Logger(ctx, nil); l != nil {
+		log = l.With(slog.String("lang", r.blog.lang))
+	}
+	log.Debug("Rendering post", slog.String("post", name), slog.String("fallback", fallback))
+
+	start := time.Now()
+	content, err := post.Render(r.markdown)
+	if err != nil {
+		span.SetError(err)
+		return err
+	}
+	r.blog.metrics.observe(r.blog.metrics.markdownDuration, start, "render")
+
+	// The index of posts is used to link to the previous, next and other parts
+	// of the series of the post.
+	ps, err := r.blog.Posts(ctx)
+	if err != nil {
+		return err
 	}
+	prev, next := posts.Neighbours(ps, post.Name)
+	series, _ := posts.SeriesOf(ps, post)
 
-	f := new(strings.Builder)
-	err = r.renderer.Render(f, c, doc)
+	ts, err := r.translations(ctx, post)
 	if err != nil {
 		return err
 	}
+	// Unpublished posts being previewed have no translations and link to all
+	// languages.
+	var links map[string]string
+	if len(ts) > 0 {
+		links = make(map[string]string, len(ts))
+		for _, t := range ts {
+			links[t.Lang] = t.Href
+		}
+	}
+
+	meta := r.meta("/blog/"+url.PathEscape(name), r.blog.lang, post.Title)
+	meta.Type = "article"
+	meta.Description = post.Description
+	meta.Published = post.Date
+	meta.Modified = post.Modified
+	meta.Tags = post.Tags
+	if len(ts) > 0 {
+		meta.Alternates = translationAlternates(ts)
+	}
+	if a, ok := post.Meta["author"].(string); ok {
+		meta.Author = a
+	}
+	// Posts with an SVG image keep the default one, as SVGs are not shown in
+	// previews.
+	if i, ok := post.Meta["image"].(string); ok && seo.PreviewableImage(i) {
+		base, err := url.Parse(meta.URL)
+		if err != nil {
+			return err
+		}
+		img, err := url.Parse(i)
+		if err != nil {
+			return err
+		}
+		meta.Image = base.ResolveReference(img).String()
+	}
 
 	return r.templates.ExecuteTemplate(w, "blog-post", map[string]any{
-		"Title":   title,
-		"Lang":    r.lang,
-		"Content": template.HTML(f.String()),
+		"Name":     post.Name,
+		"Title":    post.Title,
+		"Meta":     meta,
+		"Lang":     r.blog.lang,
+		"Content":  template.HTML(content),
+		"Tags":     post.Tags,
+		"Category": post.Category,
+
+		"WordCount":   post.WordCount(),
+		"ReadingTime": int(post.ReadingTime().Minutes()),
+		"Outline":     post.Outline(),
+
+		"Translations": links,
+		"Fallback":     fallback,
+
+		"Previous": prev,
+		"Next":     next,
+		"Series":   series,
 	})
 }
 
 type listRenderer struct {
 	templates templates.ITemplate
-	lang      string
+	blog      *blog
+	meta      func(path, lang, title string) seo.Page
 }
 
 var _ plugin.Renderer = (*listRenderer)(nil)
@@ -400,33 +661,27 @@ func (r *listRenderer) Name() string {
 }
 
 func (r *listRenderer) Render(src fs.File, w io.Writer) error {
-	d, ok := src.(fs.ReadDirFile)
-	if !ok {
+	if _, ok := src.(fs.ReadDirFile); !ok {
 		return errors.New("renderer does not support single files")
 	}
 
-	entries, err := d.ReadDir(-1)
-	if err != nil && !errors.Is(err, io.EOF) {
+	ctx, span := trace.Start(writerContext(w), "blog.list")
+	defer span.Finish()
+	span.SetAttr("lang", r.blog.lang)
+
+	ps, err := r.blog.Posts(ctx)
+	if err != nil {
 		return err
 	}
 
-	sort.Slice(entries, func(i, j int) bool {
-		return natsort.Compare(entries[i].Name(), entries[j].Name())
-	})
-
-	links := map[string]string{}
-	for _, e := range entries {
-		n := e.Name()
-		if strings.HasPrefix(n, ".") ||
-			e.IsDir() ||
-			slices.Contains([]string{
-				"LICENSE",
-				"README.md",
-			}, n) {
-			continue
-		}
-		links[n] = r.lang
+	summaries := make([]posts.Summary, len(ps))
+	for i, p := range ps {
+		summaries[i] = p.Summary()
 	}
 
-	return r.templates.ExecuteTemplate(w, "blog", links)
+	return r.templates.ExecuteTemplate(w, "blog", map[string]any{
+		"Lang":  r.blog.lang,
+		"Meta":  r.meta("/blog/", r.blog.lang, locales.Registry().T(r.blog.lang, "blog.title")),
+		"Posts": summaries,
+	})
 }
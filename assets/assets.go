@@ -5,7 +5,7 @@ import (
 	"io/fs"
 )
 
-//go:embed stylesheets/out.css icon.svg fonts/*.ttf fonts/*.woff fonts/*.woff2 fonts/*.otf
+//go:embed stylesheets/out.css icon.svg og-image.png fonts/*.ttf fonts/*.woff fonts/*.woff2 fonts/*.otf well-known/*
 var files embed.FS
 
 func Files(local ...bool) fs.FS {
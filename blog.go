@@ -0,0 +1,322 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"capytal.cc/internals/posts"
+	"capytal.cc/internals/trace"
+	"forge.capytal.company/loreddev/blogo"
+	"forge.capytal.company/loreddev/blogo/plugin"
+	"forge.capytal.company/loreddev/blogo/plugins"
+	"forge.capytal.company/loreddev/blogo/plugins/gitea"
+)
+
+// BlogConfig is the source of the blog posts of a language, a repository in a
+// Gitea/Forgejo forge.
+type BlogConfig struct {
+	// Lang is the language tag of the posts, e.g. "pt-BR".
+	Lang string `json:"lang" yaml:"lang"`
+
+	// Forge is the base URL of the forge instance.
+	Forge string `json:"forge" yaml:"forge"`
+	Owner string `json:"owner" yaml:"owner"`
+	Repo  string `json:"repo" yaml:"repo"`
+	// Ref is the branch, tag or commit to read the posts from. Defaults to the
+	// repository's default branch.
+	Ref string `json:"ref,omitempty" yaml:"ref,omitempty"`
+}
+
+// DefaultForge is the forge instance the default blogs and pages are read from.
+const DefaultForge = "https://forge.capytal.company"
+
+// DefaultBlogs are the blogs served by the application if none is configured.
+var DefaultBlogs = []BlogConfig{
+	{Lang: "en-US", Forge: DefaultForge, Owner: "capytal", Repo: "capytal.cc-blog"},
+	{Lang: "pt-BR", Forge: DefaultForge, Owner: "capytal", Repo: "capytal.cc-blog", Ref: "main-pt"},
+}
+
+type blog struct {
+	blogo.Blogo
+
+	lang     string
+	source   plugin.Plugin
+	renderer *blogPostRenderer
+	metrics  *appMetrics
+	log      *slog.Logger
+
+	// sourceDates leaves undated posts without a date, see [WithSourceDates].
+	sourceDates bool
+
+	// sourceCtx is the context of the request loading the posts, if any.
+	sourceCtx atomic.Pointer[context.Context]
+
+	posts  postsCache
+	search searchIndex
+}
+
+// postsTTL is how long the posts loaded from a blog's source are reused before
+// being loaded again.
+const postsTTL = time.Minute
+
+type postsCache struct {
+	mu          sync.Mutex
+	all         []*posts.Post
+	published   []*posts.Post
+	nextPublish time.Time
+	loaded      time.Time
+	fingerprint string
+	version     uint64
+
+	fsys fs.FS
+	// gone are the names of posts that were published but no longer exist.
+	gone map[string]bool
+}
+
+// Posts returns the published posts of the blog, loading them from its source
+// plugin if they were not loaded in the last [postsTTL]. Drafts and posts
+// scheduled to the future are not included.
+func (b *blog) Posts(ctx context.Context) ([]*posts.Post, error) {
+	ps, _, err := b.versionedPosts(ctx)
+	return ps, err
+}
+
+// Version returns a number that changes every time the published posts of the
+// blog change, so data derived from them, such as indexes, knows when it needs
+// to be regenerated.
+func (b *blog) Version(ctx context.Context) (uint64, error) {
+	_, v, err := b.versionedPosts(ctx)
+	return v, err
+}
+
+// Post returns the published post with the name, or nil if there is none.
+func (b *blog) Post(ctx context.Context, name string) (*posts.Post, error) {
+	ps, err := b.Posts(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, p := range ps {
+		if p.Name == name {
+			return p, nil
+		}
+	}
+	return nil, nil
+}
+
+// Exists reports if the blog's source has a file with the name, either a post or
+// any other file, such as images.
+func (b *blog) Exists(ctx context.Context, name string) (bool, error) {
+	if _, _, err := b.versionedPosts(ctx); err != nil {
+		return false, err
+	}
+
+	b.posts.mu.Lock()
+	fsys := b.posts.fsys
+	b.posts.mu.Unlock()
+
+	_, err := fs.Stat(fsys, name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// Gone reports if the post with the name was published, but was removed from
+// the blog's source since then.
+func (b *blog) Gone(name string) bool {
+	b.posts.mu.Lock()
+	defer b.posts.mu.Unlock()
+
+	return b.posts.gone[name]
+}
+
+// Hidden reports if the post with the name exists but is not published.
+func (b *blog) Hidden(ctx context.Context, name string) (bool, error) {
+	p, err := b.Loaded(ctx, name)
+	if err != nil || p == nil {
+		return false, err
+	}
+	return !p.IsPublished(time.Now()), nil
+}
+
+// Loaded returns the post with the name, published or not, or nil if there is
+// none. It is the same post listed in the blog's indexes, if published.
+func (b *blog) Loaded(ctx context.Context, name string) (*posts.Post, error) {
+	if _, _, err := b.versionedPosts(ctx); err != nil {
+		return nil, err
+	}
+
+	b.posts.mu.Lock()
+	defer b.posts.mu.Unlock()
+
+	for _, p := range b.posts.all {
+		if p.Name == name {
+			return p, nil
+		}
+	}
+	return nil, nil
+}
+
+func (b *blog) versionedPosts(ctx context.Context) ([]*posts.Post, uint64, error) {
+	b.posts.mu.Lock()
+	defer b.posts.mu.Unlock()
+
+	now := time.Now()
+
+	if b.posts.all == nil || now.Sub(b.posts.loaded) >= postsTTL {
+		ps, fsys, err := b.load(ctx)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		if b.posts.gone == nil {
+			b.posts.gone = map[string]bool{}
+		}
+		for _, p := range b.posts.published {
+			b.posts.gone[p.Name] = true
+		}
+		for _, p := range ps {
+			delete(b.posts.gone, p.Name)
+		}
+
+		published, next := posts.Published(ps, now)
+
+		f := posts.Fingerprint(ps)
+		if f != b.posts.fingerprint || len(published) != len(b.posts.published) {
+			b.posts.version++
+		}
+
+		b.posts.all = ps
+		b.posts.fsys = fsys
+		b.posts.published, b.posts.nextPublish = published, next
+		b.posts.fingerprint = f
+		b.posts.loaded = now
+	}
+
+	// A scheduled post reached its publish date.
+	if !b.posts.nextPublish.IsZero() && !now.Before(b.posts.nextPublish) {
+		b.posts.published, b.posts.nextPublish = posts.Published(b.posts.all, now)
+		b.posts.version++
+	}
+
+	return b.posts.published, b.posts.version, nil
+}
+
+// Refresh discards the loaded posts, so they are loaded again from the source on
+// the next use.
+func (b *blog) Refresh() {
+	b.posts.mu.Lock()
+	defer b.posts.mu.Unlock()
+
+	b.posts.all = nil
+}
+
+// load reads the posts from the blog's source. The requests the source makes
+// while loading are traced and logged as part of the request of ctx, as
+// sourcer plugins are not given a context.
+func (b *blog) load(ctx context.Context) ([]*posts.Post, fs.FS, error) {
+	s, ok := b.source.(plugin.Sourcer)
+	if !ok {
+		return nil, nil, fmt.Errorf("plugin %q is not a sourcer", b.source.Name())
+	}
+
+	ctx, span := trace.Start(ctx, "blog.load")
+	defer span.Finish()
+	span.SetAttr("lang", b.lang)
+
+	log := b.log
+	if l := trace.Logger(ctx, nil); l != nil {
+		log = l.With(slog.String("lang", b.lang))
+	}
+	log.Debug("Loading posts")
+
+	b.sourceCtx.Store(&ctx)
+	defer b.sourceCtx.Store(nil)
+
+	start := time.Now()
+
+	fsys, err := s.Source()
+	if err != nil {
+		span.SetError(err)
+		b.metrics.blogLoadErrors.Inc(b.lang)
+		return nil, nil, fmt.Errorf("%w: %w", errUnavailable, err)
+	}
+
+	var opts []posts.LoadOption
+	if b.sourceDates {
+		opts = append(opts, posts.WithoutModTime())
+	}
+
+	ps, err := posts.Load(b.renderer.markdown, fsys, log, opts...)
+	if err != nil {
+		span.SetError(err)
+		b.metrics.blogLoadErrors.Inc(b.lang)
+		return nil, nil, err
+	}
+
+	b.metrics.observe(b.metrics.blogLoadDuration, start, b.lang)
+	return ps, fsys, nil
+}
+
+func (app *app) newBlog(c BlogConfig) *blog {
+	log := app.log.With(slog.String("lang", c.Lang))
+	// Blogo logs with the context of requests, if any, so its records carry their
+	// request and trace IDs.
+	b := blogo.New(blogo.Opts{
+		Assertions: app.assert,
+		Logger:     slog.New(trace.NewHandler(app.log.Handler())).WithGroup("blogo").With("lang", c.Lang),
+	})
+
+	bl := &blog{Blogo: b, lang: c.Lang, metrics: app.metrics, log: log, sourceDates: app.sourceDates}
+
+	var source plugin.Plugin = gitea.New(c.Owner, c.Repo, c.Forge, gitea.Opts{
+		Ref:        c.Ref,
+		HTTPClient: &http.Client{Transport: bl.sourceTransport(app.metrics.transport(tracingTransport(http.DefaultTransport)))},
+	})
+	if fsys, ok := app.blogSources[c.Lang]; ok {
+		source = newFSSource(fsys)
+	}
+	b.Use(source)
+	bl.source = source
+
+	bl.renderer = NewBlogPostRenderer(app.templates, bl, app.pageMeta, app.translations, app.markdown)
+
+	b.Use(&listRenderer{app.templates, bl, app.pageMeta})
+	b.Use(bl.renderer)
+	b.Use(plugins.NewPlainText())
+
+	return bl
+}
+
+// sourceTransport makes the requests of the blog's source, made while loading
+// its posts, part of the request that caused the load.
+func (b *blog) sourceTransport(next http.RoundTripper) http.RoundTripper {
+	return roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		if ctx := b.sourceCtx.Load(); ctx != nil && trace.FromContext(r.Context()) == nil {
+			r = r.WithContext(trace.Inherit(r.Context(), *ctx))
+		}
+		return next.RoundTrip(r)
+	})
+}
+
+// blog returns the blog of the language, or the first configured blog if there
+// is none.
+func (app *app) blog(lang string) *blog {
+	app.assert.NotZero(app.blogs, "At least one blog should be configured")
+
+	for _, b := range app.blogs {
+		if strings.EqualFold(b.lang, lang) {
+			return b
+		}
+	}
+	return app.blogs[0]
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestBlogPostRenderer(t *testing.T) {
+	modTime := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	source := fstest.MapFS{
+		"undated.md": {Data: []byte("# Undated\n\nA post without a date.\n"), ModTime: modTime},
+		"draft.md":   {Data: []byte("---\ndate: 2025-01-01\ndraft: true\n---\n\n# Draft\n")},
+		"notes.txt":  {Data: []byte("notes\n")},
+	}
+
+	h, err := NewApp(WithPages(), WithCacheDisabled(), WithBlogSource("en-US", source), WithBlogSource("pt-BR", source))
+	if err != nil {
+		t.Fatal(err)
+	}
+	blog := h.(*app).blog("en-US")
+
+	render := func(ctx context.Context, name string) (string, error) {
+		f, err := source.Open(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+
+		w := httptest.NewRecorder()
+		err = blog.renderer.Render(f, &contextWriter{w, ctx})
+		return w.Body.String(), err
+	}
+
+	// The rendered post is the one of the index, dated by its modification time.
+	body, err := render(context.Background(), "undated.md")
+	if err != nil {
+		t.Fatalf("Render(undated.md) error = %v", err)
+	}
+	for _, want := range []string{
+		`<meta property="article:published_time" content="2025-03-01T00:00:00Z">`,
+		`<meta property="article:modified_time" content="2025-03-01T00:00:00Z">`,
+		`<meta property="og:image" content="https://capytal.cc/assets/og-image.png">`,
+		`<meta name="twitter:image" content="https://capytal.cc/assets/og-image.png">`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Render(undated.md) does not contain %s", want)
+		}
+	}
+	if p, _ := blog.Post(context.Background(), "undated.md"); p == nil || !p.Date.Equal(modTime) {
+		t.Errorf("Post(undated.md) = %+v, want a post dated %s", p, modTime)
+	}
+
+	if _, err := render(context.Background(), "draft.md"); err == nil {
+		t.Error("Render(draft.md) rendered a draft without a preview")
+	}
+	if _, err := render(withPreview(context.Background()), "draft.md"); err != nil {
+		t.Errorf("Render(draft.md) error = %v in a preview", err)
+	}
+	if _, err := render(context.Background(), "notes.txt"); err == nil {
+		t.Error("Render(notes.txt) rendered a file which is not a post")
+	}
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/blog/draft.md?lang=en-US", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("GET draft.md = %d, want %d", w.Code, http.StatusNotFound)
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+// cached serves the responses of the handler from the response cache, if it is
+// enabled.
+func (app *app) cached(next http.Handler) http.Handler {
+	if app.responses == nil {
+		return next
+	}
+	return app.responses.Middleware(next)
+}
+
+// authorizeAdmin reports if the request is authenticated with the admin token as
+// a bearer token, responding with an error if it is not. Administration endpoints
+// are not found if no token is set.
+func (app *app) authorizeAdmin(w http.ResponseWriter, r *http.Request) bool {
+	if app.adminToken == "" {
+		app.notFound(w, r)
+		return false
+	}
+
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(app.adminToken)) != 1 {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		app.fail(w, r, http.StatusUnauthorized, errors.New("unauthorized"))
+		return false
+	}
+	return true
+}
+
+// purgeCache removes responses from the response cache. The "prefix" and "tag"
+// form values select the responses of the paths starting with the prefix and
+// with the tag respectively; if both are empty, the whole cache is purged.
+// Requests must be authenticated with the admin token as a bearer token.
+func (app *app) purgeCache() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if app.adminToken == "" || app.responses == nil {
+			app.notFound(w, r)
+			return
+		}
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			app.fail(w, r, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+			return
+		}
+
+		if !app.authorizeAdmin(w, r) {
+			return
+		}
+
+		prefix, tag := r.FormValue("prefix"), r.FormValue("tag")
+
+		n := 0
+		if prefix != "" {
+			n += app.responses.Purge(prefix)
+		}
+		if tag != "" {
+			n += app.responses.PurgeTag(tag)
+		}
+		if prefix == "" && tag == "" {
+			n = app.responses.Purge("")
+		}
+
+		app.logger(r.Context()).Info("Purged response cache",
+			slog.String("prefix", prefix),
+			slog.String("tag", tag),
+			slog.Int("purged", n),
+		)
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(map[string]int{"purged": n}); err != nil {
+			app.logger(r.Context()).Error("Failed to write purge response", slog.String("error", err.Error()))
+		}
+	})
+}
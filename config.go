@@ -0,0 +1,481 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"capytal.cc/locales"
+	"github.com/alecthomas/chroma/v2/styles"
+	"gopkg.in/yaml.v2"
+)
+
+// envPrefix is the prefix of the environment variables of configuration keys,
+// e.g. "CAPYTAL_BASE_URL" for "base_url".
+const envPrefix = "CAPYTAL_"
+
+// Config is the configuration of the server. It is merged from, in increasing
+// order of precedence, the defaults, a YAML configuration file, environment
+// variables and command-line flags. JSON files are valid YAML files.
+//
+// Each key of the file can be set by the environment variable of its name in
+// upper case prefixed by "CAPYTAL_", and by the flag of its name with dashes
+// instead of underscores, except for secrets, which can't be set with flags.
+// As environment variables and flags are strings, "blogs" and "pages" are set
+// with the path of a file containing their list, and "trusted_domains" with a
+// comma-separated list.
+type Config struct {
+	Hostname string `yaml:"hostname"`
+	Port     uint   `yaml:"port"`
+	BaseURL  string `yaml:"base_url"`
+	Dev      bool   `yaml:"dev"`
+	Verbose  bool   `yaml:"verbose"`
+
+	// Templates is the directory of templates to be used instead of the
+	// built-in ones.
+	Templates string `yaml:"templates"`
+	// BlogDir is the directory to read blog posts from instead of the forge.
+	BlogDir string `yaml:"blog_dir"`
+	// PagesDir is the directory to read the pages with local sources from.
+	PagesDir string `yaml:"pages_dir"`
+
+	// Forge is the base URL of the forge of the blogs without one, and of the
+	// default pages. Defaults to [DefaultForge].
+	Forge string `yaml:"forge"`
+	// Blogs defaults to [DefaultBlogs], read from Forge.
+	Blogs []BlogConfig `yaml:"blogs"`
+	// Pages defaults to [DefaultPages], read from Forge.
+	Pages []PageConfig `yaml:"pages"`
+	// PrivacyPolicy replaces the source of the "/privacy/" page.
+	PrivacyPolicy string `yaml:"privacy_policy"`
+
+	TrustedDomains []string `yaml:"trusted_domains"`
+	HighlightStyle string   `yaml:"highlight_style"`
+
+	PreviewSecret string `yaml:"preview_secret"`
+	WebhookSecret string `yaml:"webhook_secret"`
+	AdminToken    string `yaml:"admin_token"`
+}
+
+// configError is an invalid configuration value, reported with its key and
+// where it was set.
+type configError struct {
+	key    string
+	source string
+	err    error
+}
+
+func (e *configError) Error() string {
+	if e.source != "" {
+		return fmt.Sprintf("invalid %s (set by %s): %s", e.key, e.source, e.err)
+	}
+	return fmt.Sprintf("invalid %s: %s", e.key, e.err)
+}
+
+func (e *configError) Unwrap() error {
+	return e.err
+}
+
+// secretKeys can't be set with flags, which are visible to other processes.
+var secretKeys = []string{"preview_secret", "webhook_secret", "admin_token"}
+
+// legacyEnv are the environment variables read before the configuration file
+// existed, still read if the prefixed variable is not set.
+var legacyEnv = map[string]string{
+	"preview_secret": "PREVIEW_SECRET",
+	"webhook_secret": "GITEA_WEBHOOK_SECRET",
+	"admin_token":    "ADMIN_TOKEN",
+}
+
+func defaultConfig() Config {
+	return Config{
+		Hostname:       "localhost",
+		Port:           8080,
+		BaseURL:        "https://capytal.cc",
+		Forge:          DefaultForge,
+		TrustedDomains: slices.Clone(DefaultTrustedDomains),
+		HighlightStyle: DefaultHighlightStyle,
+	}
+}
+
+// loadConfig reads the configuration file at path, if it is not empty, then
+// the environment variables and the flags of fs that were set, and validates
+// the result.
+func loadConfig(path string, fs *flag.FlagSet) (Config, error) {
+	c := defaultConfig()
+
+	if path != "" {
+		f, err := os.ReadFile(path)
+		if err != nil {
+			return c, fmt.Errorf("unable to read configuration file: %w", err)
+		}
+		if err := decodeYAML(f, &c, ""); err != nil {
+			return c, withSource(err, "configuration", path)
+		}
+	}
+
+	for _, key := range configKeys() {
+		name := envPrefix + strings.ToUpper(key)
+		v := getEnv(name, "")
+		if v == "" && legacyEnv[key] != "" {
+			name = legacyEnv[key]
+			v = getEnv(name, "")
+		}
+		if v == "" {
+			continue
+		}
+		if err := c.set(key, v); err != nil {
+			return c, withSource(err, key, name)
+		}
+	}
+
+	var err error
+	fs.Visit(func(f *flag.Flag) {
+		key := strings.ReplaceAll(f.Name, "-", "_")
+		if err != nil || !slices.Contains(configKeys(), key) || slices.Contains(secretKeys, key) {
+			return
+		}
+		if e := c.set(key, f.Value.String()); e != nil {
+			err = withSource(e, key, "-"+f.Name)
+		}
+	})
+	if err != nil {
+		return c, err
+	}
+
+	c.resolve()
+
+	return c, c.validate()
+}
+
+// configKeys returns the keys of the configuration file.
+func configKeys() []string {
+	return []string{
+		"hostname", "port", "base_url", "dev", "verbose",
+		"templates", "blog_dir", "pages_dir",
+		"forge", "blogs", "pages", "privacy_policy",
+		"trusted_domains", "highlight_style",
+		"preview_secret", "webhook_secret", "admin_token",
+	}
+}
+
+// set sets the key to a value from an environment variable or flag.
+func (c *Config) set(key, v string) error {
+	switch key {
+	case "hostname":
+		c.Hostname = v
+	case "port":
+		p, err := strconv.ParseUint(v, 10, 16)
+		if err != nil {
+			return fmt.Errorf("%q is not a port number", v)
+		}
+		c.Port = uint(p)
+	case "base_url":
+		c.BaseURL = v
+	case "dev", "verbose":
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("%q is not a boolean", v)
+		}
+		if key == "dev" {
+			c.Dev = b
+		} else {
+			c.Verbose = b
+		}
+	case "templates":
+		c.Templates = v
+	case "blog_dir":
+		c.BlogDir = v
+	case "pages_dir":
+		c.PagesDir = v
+	case "forge":
+		c.Forge = v
+	case "blogs":
+		c.Blogs = nil
+		return readConfigList(v, &c.Blogs, key)
+	case "pages":
+		c.Pages = nil
+		return readConfigList(v, &c.Pages, key)
+	case "privacy_policy":
+		c.PrivacyPolicy = v
+	case "trusted_domains":
+		c.TrustedDomains = nil
+		for _, d := range strings.Split(v, ",") {
+			if d = strings.TrimSpace(d); d != "" {
+				c.TrustedDomains = append(c.TrustedDomains, d)
+			}
+		}
+	case "highlight_style":
+		c.HighlightStyle = v
+	case "preview_secret":
+		c.PreviewSecret = v
+	case "webhook_secret":
+		c.WebhookSecret = v
+	case "admin_token":
+		c.AdminToken = v
+	default:
+		return errors.New("unknown configuration key")
+	}
+	return nil
+}
+
+// readConfigList reads a JSON or YAML file with a list of blogs or pages, the
+// value of key.
+func readConfigList[T any](path string, l *[]T, key string) error {
+	f, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return decodeYAML(f, l, key)
+}
+
+// withSource sets the source of the errors of the keys of a value set by source,
+// or reports err as an error of key if it has none.
+func withSource(err error, key, source string) error {
+	errs := []error{err}
+	if j, ok := err.(interface{ Unwrap() []error }); ok {
+		errs = j.Unwrap()
+	}
+
+	var keyErrs []*configError
+	for _, e := range errs {
+		var ke *configError
+		if !errors.As(e, &ke) {
+			return &configError{key, source, err}
+		}
+		keyErrs = append(keyErrs, ke)
+	}
+	for _, ke := range keyErrs {
+		ke.source = source
+	}
+	return err
+}
+
+// decodeYAML strictly decodes data into v, the value of key. As yaml.v2 only
+// reports the lines of the values it can't decode, the values are decoded
+// again one by one on errors, to report the keys of the invalid ones.
+func decodeYAML(data []byte, v any, key string) error {
+	err := yaml.UnmarshalStrict(data, v)
+	if err == nil {
+		return nil
+	}
+
+	// Syntax errors can't be decoded at all, and are reported with their line.
+	var node any
+	if yaml.Unmarshal(data, &node) != nil {
+		return err
+	}
+	if errs := yamlKeyErrors(node, reflect.TypeOf(v).Elem(), key); len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+	return err
+}
+
+// yamlLine matches the line prefixed to the errors of yaml.v2, which is
+// meaningless for values decoded alone.
+var yamlLine = regexp.MustCompile(`^(yaml: unmarshal errors:\s*)?line \d+: `)
+
+// yamlKeyErrors decodes the node, decoded from YAML without a type, into a value
+// of type t, reporting the errors of each key of it.
+func yamlKeyErrors(node any, t reflect.Type, key string) []error {
+	switch m, isMap := node.(map[any]any); {
+	case t.Kind() == reflect.Struct && isMap:
+		keys := make([]string, 0, len(m))
+		for k := range m {
+			keys = append(keys, fmt.Sprint(k))
+		}
+		sort.Strings(keys)
+
+		var errs []error
+		for _, k := range keys {
+			name := k
+			if key != "" {
+				name = key + "." + k
+			}
+			f, ok := yamlField(t, k)
+			if !ok {
+				errs = append(errs, &configError{key: name, err: errors.New("unknown key")})
+				continue
+			}
+			errs = append(errs, yamlKeyErrors(m[k], f.Type, name)...)
+		}
+		return errs
+
+	case t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Struct:
+		l, ok := node.([]any)
+		if !ok {
+			break
+		}
+		var errs []error
+		for i, n := range l {
+			errs = append(errs, yamlKeyErrors(n, t.Elem(), fmt.Sprintf("%s[%d]", key, i))...)
+		}
+		return errs
+	}
+
+	b, err := yaml.Marshal(node)
+	if err == nil {
+		err = yaml.UnmarshalStrict(b, reflect.New(t).Interface())
+	}
+	if err == nil {
+		return nil
+	}
+	if key == "" {
+		key = "configuration"
+	}
+	msg := yamlLine.ReplaceAllString(strings.TrimSpace(err.Error()), "")
+	return []error{&configError{key: key, err: errors.New(msg)}}
+}
+
+// yamlField returns the field of the struct type t with the YAML key.
+func yamlField(t reflect.Type, key string) (reflect.StructField, bool) {
+	for i := range t.NumField() {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+		if f.IsExported() && name == key {
+			return f, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+// resolve fills the blogs and pages that were not configured with the defaults,
+// read from the configured forge.
+func (c *Config) resolve() {
+	if c.Blogs == nil {
+		c.Blogs = slices.Clone(DefaultBlogs)
+		for i := range c.Blogs {
+			c.Blogs[i].Forge = c.Forge
+		}
+	}
+	for i := range c.Blogs {
+		if c.Blogs[i].Forge == "" {
+			c.Blogs[i].Forge = c.Forge
+		}
+	}
+
+	if c.Pages == nil {
+		c.Pages = slices.Clone(DefaultPages)
+		for i, p := range c.Pages {
+			if rest, ok := strings.CutPrefix(p.Source, DefaultForge); ok {
+				c.Pages[i].Source = c.Forge + rest
+			}
+		}
+	}
+	if c.PrivacyPolicy != "" {
+		for i, p := range c.Pages {
+			if p.Path == "/privacy/" {
+				c.Pages[i].Source = c.PrivacyPolicy
+			}
+		}
+	}
+}
+
+// validate reports all invalid values of the configuration, each naming its key.
+func (c *Config) validate() error {
+	var errs []error
+	fail := func(key string, format string, args ...any) {
+		errs = append(errs, &configError{key: key, err: fmt.Errorf(format, args...)})
+	}
+
+	if c.Port == 0 || c.Port > 65535 {
+		fail("port", "must be between 1 and 65535, got %d", c.Port)
+	}
+	if err := validateHTTPURL(c.BaseURL); err != nil {
+		fail("base_url", "%w", err)
+	}
+	if err := validateHTTPURL(c.Forge); err != nil {
+		fail("forge", "%w", err)
+	}
+
+	for _, d := range [][2]string{{"templates", c.Templates}, {"blog_dir", c.BlogDir}, {"pages_dir", c.PagesDir}} {
+		key, dir := d[0], d[1]
+		if dir == "" {
+			continue
+		}
+		if info, err := os.Stat(dir); err != nil {
+			fail(key, "%w", err)
+		} else if !info.IsDir() {
+			fail(key, "%s is not a directory", dir)
+		}
+	}
+
+	if len(c.Blogs) == 0 {
+		fail("blogs", "at least one blog must be configured")
+	}
+	langs := map[string]bool{}
+	for i, b := range c.Blogs {
+		key := fmt.Sprintf("blogs[%d]", i)
+		if _, ok := locales.Registry().Lookup(b.Lang); !ok {
+			fail(key+".lang", "%q is not a supported language", b.Lang)
+		} else if langs[strings.ToLower(b.Lang)] {
+			fail(key+".lang", "%q is configured more than once", b.Lang)
+		}
+		langs[strings.ToLower(b.Lang)] = true
+
+		// Posts are read from the directory instead of the forge.
+		if c.BlogDir != "" {
+			continue
+		}
+		if err := validateHTTPURL(b.Forge); err != nil {
+			fail(key+".forge", "%w", err)
+		}
+		if b.Owner == "" {
+			fail(key+".owner", "must be set")
+		}
+		if b.Repo == "" {
+			fail(key+".repo", "must be set")
+		}
+	}
+
+	paths := map[string]int{}
+	for i, p := range c.Pages {
+		key := fmt.Sprintf("pages[%d]", i)
+		if err := p.validatePath(); err != nil {
+			fail(key+".path", "%w", err)
+		} else if j, ok := paths[p.Path]; ok {
+			fail(key+".path", "%q is already the path of pages[%d]", p.Path, j)
+		} else {
+			paths[p.Path] = i
+		}
+
+		if err := p.validate(); err != nil {
+			fail(key, "%w", err)
+		} else if !p.remote() && c.PagesDir == "" {
+			fail(key+".source", "%q is a local source, but pages_dir is not set", p.Source)
+		}
+	}
+	if c.PrivacyPolicy != "" && !slices.ContainsFunc(c.Pages, func(p PageConfig) bool { return p.Path == "/privacy/" }) {
+		fail("privacy_policy", `no page is configured at "/privacy/"`)
+	}
+
+	for _, d := range c.TrustedDomains {
+		if d == "" || strings.ContainsAny(d, "/: ") {
+			fail("trusted_domains", "%q is not a domain", d)
+		}
+	}
+	if _, ok := styles.Registry[c.HighlightStyle]; !ok {
+		fail("highlight_style", "unknown style %q", c.HighlightStyle)
+	}
+
+	return errors.Join(errs...)
+}
+
+func validateHTTPURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return err
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%q is not an absolute HTTP URL", raw)
+	}
+	return nil
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// clearConfigEnv unsets the environment variables of all configuration keys
+// for the duration of the test.
+func clearConfigEnv(t *testing.T) {
+	t.Helper()
+	for _, key := range configKeys() {
+		t.Setenv(envPrefix+strings.ToUpper(key), "")
+	}
+	for _, name := range legacyEnv {
+		t.Setenv(name, "")
+	}
+}
+
+func writeTestFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func testFlagSet(t *testing.T, args ...string) *flag.FlagSet {
+	t.Helper()
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.String("hostname", "localhost", "")
+	fs.Uint("port", 8080, "")
+	fs.Bool("dev", false, "")
+	fs.String("webhook-secret", "", "")
+	fs.String("preview", "", "")
+	if err := fs.Parse(args); err != nil {
+		t.Fatal(err)
+	}
+	return fs
+}
+
+func TestLoadConfigPrecedence(t *testing.T) {
+	tests := []struct {
+		name  string
+		file  string
+		env   map[string]string
+		flags []string
+		want  func(c Config) bool
+	}{
+		{
+			name: "defaults",
+			want: func(c Config) bool { return c.Hostname == "localhost" && c.Port == 8080 && !c.Dev },
+		},
+		{
+			name: "file",
+			file: "hostname: file\nport: 1\ndev: true\n",
+			want: func(c Config) bool { return c.Hostname == "file" && c.Port == 1 && c.Dev },
+		},
+		{
+			name: "environment over file",
+			file: "hostname: file\nport: 1\n",
+			env:  map[string]string{"CAPYTAL_HOSTNAME": "env"},
+			want: func(c Config) bool { return c.Hostname == "env" && c.Port == 1 },
+		},
+		{
+			name:  "flags over environment and file",
+			file:  "hostname: file\nport: 1\n",
+			env:   map[string]string{"CAPYTAL_HOSTNAME": "env", "CAPYTAL_PORT": "2"},
+			flags: []string{"-hostname", "flag"},
+			want:  func(c Config) bool { return c.Hostname == "flag" && c.Port == 2 },
+		},
+		{
+			name:  "flags set to their defaults",
+			env:   map[string]string{"CAPYTAL_HOSTNAME": "env"},
+			flags: []string{"-hostname", "localhost"},
+			want:  func(c Config) bool { return c.Hostname == "localhost" },
+		},
+		{
+			name: "legacy environment over file",
+			file: "webhook_secret: file\n",
+			env:  map[string]string{"GITEA_WEBHOOK_SECRET": "legacy"},
+			want: func(c Config) bool { return c.WebhookSecret == "legacy" },
+		},
+		{
+			name: "prefixed environment over legacy environment",
+			env:  map[string]string{"GITEA_WEBHOOK_SECRET": "legacy", "CAPYTAL_WEBHOOK_SECRET": "env"},
+			want: func(c Config) bool { return c.WebhookSecret == "env" },
+		},
+		{
+			name:  "secrets are not set by flags",
+			file:  "webhook_secret: file\n",
+			flags: []string{"-webhook-secret", "flag"},
+			want:  func(c Config) bool { return c.WebhookSecret == "file" },
+		},
+		{
+			name:  "flags other than keys are ignored",
+			flags: []string{"-preview", "post.md"},
+			want:  func(c Config) bool { return c.Hostname == "localhost" },
+		},
+		{
+			name: "comma-separated trusted domains",
+			env:  map[string]string{"CAPYTAL_TRUSTED_DOMAINS": "a.example, b.example,"},
+			want: func(c Config) bool { return strings.Join(c.TrustedDomains, " ") == "a.example b.example" },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearConfigEnv(t)
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+
+			var path string
+			if tt.file != "" {
+				path = writeTestFile(t, "config.yaml", tt.file)
+			}
+
+			c, err := loadConfig(path, testFlagSet(t, tt.flags...))
+			if err != nil {
+				t.Fatalf("loadConfig() error = %v", err)
+			}
+			if !tt.want(c) {
+				t.Errorf("unexpected configuration %+v", c)
+			}
+		})
+	}
+}
+
+func TestLoadConfigErrors(t *testing.T) {
+	blogs := writeTestFile(t, "blogs.yaml", "- lang: en-US\n  owner: [capytal]\n  repo: blog\n  branch: main\n")
+
+	tests := []struct {
+		name  string
+		file  string
+		env   map[string]string
+		flags []string
+		// errs are the substrings expected in the error.
+		errs []string
+	}{
+		{
+			name: "file type error",
+			file: "hostname: localhost\nport: abc\n",
+			errs: []string{"invalid port (set by config.yaml)", "cannot unmarshal !!str `abc` into uint"},
+		},
+		{
+			name: "file unknown key",
+			file: "hostnme: localhost\n",
+			errs: []string{"invalid hostnme (set by config.yaml): unknown key"},
+		},
+		{
+			name: "file errors in lists",
+			file: "dev: maybe\nblogs:\n  - lang: en-US\n    owner: capytal\n    repo: 3\n  - lang: [pt-BR]\n",
+			errs: []string{"invalid dev", "invalid blogs[1].lang"},
+		},
+		{
+			name: "file syntax error",
+			file: "hostname: [localhost\n",
+			errs: []string{"invalid configuration (set by config.yaml)", "line"},
+		},
+		{
+			name: "environment list file",
+			env:  map[string]string{"CAPYTAL_BLOGS": blogs},
+			errs: []string{"invalid blogs[0].owner (set by CAPYTAL_BLOGS)", "invalid blogs[0].branch (set by CAPYTAL_BLOGS): unknown key"},
+		},
+		{
+			name: "environment value",
+			env:  map[string]string{"CAPYTAL_PORT": "http"},
+			errs: []string{`invalid port (set by CAPYTAL_PORT): "http" is not a port number`},
+		},
+		{
+			name:  "flag value",
+			flags: []string{"-port", "0"},
+			errs:  []string{"invalid port: must be between 1 and 65535, got 0"},
+		},
+		{
+			name: "validation",
+			file: "base_url: capytal.cc\nblogs:\n  - lang: xx\n    forge: forge.example\n    owner: capytal\n    repo: blog\n",
+			errs: []string{"invalid base_url", `invalid blogs[0].lang: "xx" is not a supported language`, "invalid blogs[0].forge"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearConfigEnv(t)
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+
+			var path string
+			if tt.file != "" {
+				path = writeTestFile(t, "config.yaml", tt.file)
+			}
+
+			_, err := loadConfig(path, testFlagSet(t, tt.flags...))
+			if err == nil {
+				t.Fatal("loadConfig() error = nil")
+			}
+			msg := err.Error()
+			if path != "" {
+				msg = strings.ReplaceAll(msg, path, "config.yaml")
+			}
+			for _, want := range tt.errs {
+				if !strings.Contains(msg, want) {
+					t.Errorf("error %q does not contain %q", msg, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"capytal.cc/locales"
+)
+
+// errUnavailable marks errors caused by a content source, such as the forge,
+// being unavailable. They are reported as 503 Service Unavailable.
+var errUnavailable = errors.New("content source unavailable")
+
+// retryAfter is the number of seconds clients are asked to wait before retrying
+// requests that failed because a content source is unavailable.
+const retryAfter = 60
+
+// problem is a JSON error response as described by RFC 9457.
+type problem struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+}
+
+// notFound responds with a 404 Not Found error page.
+func (app *app) notFound(w http.ResponseWriter, r *http.Request) {
+	app.fail(w, r, http.StatusNotFound, nil)
+}
+
+// serverError responds with a 500 Internal Server Error page, or 503 Service
+// Unavailable if the error was caused by an unavailable content source.
+func (app *app) serverError(w http.ResponseWriter, r *http.Request, err error) {
+	status := http.StatusInternalServerError
+	if errors.Is(err, errUnavailable) {
+		status = http.StatusServiceUnavailable
+		w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+	}
+	app.fail(w, r, status, err)
+}
+
+// fail responds with an error page of the status, localized to the language of
+// the request. Clients preferring JSON receive the error as problem details. The
+// underlying error, if any, is logged and never shown to the client.
+func (app *app) fail(w http.ResponseWriter, r *http.Request, status int, err error) {
+	attrs := []any{
+		slog.Int("status", status),
+		slog.String("method", r.Method),
+		slog.String("path", r.URL.Path),
+	}
+	if err != nil {
+		attrs = append(attrs, slog.String("error", err.Error()))
+	}
+	log := app.logger(r.Context())
+	if status >= http.StatusInternalServerError {
+		log.Error("Request failed", attrs...)
+	} else {
+		log.Debug("Request failed", attrs...)
+	}
+
+	lang := requestLang(r)
+	registry := locales.Registry()
+
+	title := registry.T(lang, fmt.Sprintf("error.%d.title", status))
+	if title == fmt.Sprintf("error.%d.title", status) {
+		title = http.StatusText(status)
+	}
+	message := registry.T(lang, fmt.Sprintf("error.%d.message", status))
+	if message == fmt.Sprintf("error.%d.message", status) {
+		message = registry.T(lang, "error.message")
+	}
+
+	h := w.Header()
+	h.Del("Content-Length")
+	h.Set("Content-Language", lang)
+	h.Set("Cache-Control", "no-store")
+	h.Add("Vary", "Accept")
+
+	if prefersJSON(r.Header.Get("Accept")) {
+		h.Set("Content-Type", "application/problem+json")
+		w.WriteHeader(status)
+		err := json.NewEncoder(w).Encode(problem{
+			Type:     "about:blank",
+			Title:    title,
+			Status:   status,
+			Detail:   message,
+			Instance: r.URL.Path,
+		})
+		if err != nil {
+			log.Error("Failed to write error response", slog.String("error", err.Error()))
+		}
+		return
+	}
+
+	h.Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+
+	meta := app.pageMeta(r.URL.Path, lang, title)
+	meta.Description = message
+
+	err = app.templates.ExecuteTemplate(w, "partials-status", map[string]any{
+		"Title":           title,
+		"Meta":            meta,
+		"Lang":            lang,
+		"StatusCode":      status,
+		"Message":         message,
+		"Redirect":        "/?lang=" + lang,
+		"RedirectMessage": registry.T(lang, "error.back"),
+	})
+	if err != nil {
+		log.Error("Failed to render error page", slog.String("error", err.Error()))
+	}
+}
+
+// requestLang returns the language of the request, from its "lang" parameter
+// or else negotiated like [langRedirect] does.
+func requestLang(r *http.Request) string {
+	registry := locales.Registry()
+
+	if l, ok := registry.Lookup(r.URL.Query().Get("lang")); ok {
+		return l.Tag
+	}
+	if c, err := r.Cookie(langCookie); err == nil {
+		if l, ok := registry.Lookup(c.Value); ok {
+			return l.Tag
+		}
+	}
+	return registry.Negotiate(r.Header.Get("Accept-Language")).Tag
+}
+
+// prefersJSON reports if the Accept header prefers JSON over HTML. Wildcards are
+// not taken into account, as browsers always ask for HTML explicitly.
+func prefersJSON(accept string) bool {
+	var jsonQ, htmlQ float64
+	for _, part := range strings.Split(accept, ",") {
+		t, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				q = f
+			}
+		}
+
+		switch t {
+		case "application/json", "application/problem+json":
+			jsonQ = max(jsonQ, q)
+		case "text/html":
+			htmlQ = max(htmlQ, q)
+		}
+	}
+	return jsonQ > 0 && jsonQ > htmlQ
+}
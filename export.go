@@ -0,0 +1,421 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"io/fs"
+	"log/slog"
+	"mime"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"capytal.cc/locales"
+)
+
+// exportRoots are the paths, relative to the root of the site and the root of
+// each language, from where the static export starts crawling. Pages not linked
+// from them, such as markdown pages, are passed as additional paths to the
+// export.
+var (
+	exportRoots     = []string{"/sitemap.xml", "/robots.txt", "/humans.txt", "/.well-known/security.txt"}
+	exportLangRoots = []string{
+		"/", "/about/",
+		"/blog/", "/blog/tags/", "/blog/categories/",
+		"/blog/feed.xml", "/blog/atom.xml", "/blog/feed.json",
+	}
+)
+
+// exportLinks matches the URL attributes of the HTML pages to be crawled and
+// rewritten. Templates always quote attributes with double quotes.
+var exportLinks = regexp.MustCompile(`\b(href|src|action)="([^"]*)"`)
+
+// exportAbsoluteLinks matches the absolute URLs of the site at base, such as the
+// ones of sitemaps, feeds and canonical links, in any kind of page.
+func exportAbsoluteLinks(base string) *regexp.Regexp {
+	return regexp.MustCompile(regexp.QuoteMeta(base) + `(/[^\s"'<>\\]*)`)
+}
+
+type exportedPage struct {
+	uri      string
+	status   int
+	header   http.Header
+	body     []byte
+	redirect string
+	file     string
+}
+
+// exporter crawls the routes served by a handler, across all languages, and
+// writes them as files of a static site. Pages of each language are written
+// under a directory named by its tag, e.g. "/blog/?lang=pt-BR" is written to
+// "pt-BR/blog/index.html", and links between pages are rewritten to match.
+type exporter struct {
+	handler  http.Handler
+	base     string
+	absLinks *regexp.Regexp
+	log      *slog.Logger
+
+	pages map[string]*exportedPage
+	order []string
+}
+
+// exportSite writes the static export of the handler and the assets to the out
+// directory. Absolute URLs of the site at base are rewritten to the exported
+// pages, as it is expected to be deployed at the same URL. The output only
+// depends on the content served, so exports of the same content are identical
+// as long as the handler dates it from its sources, see [WithSourceDates].
+func exportSite(handler http.Handler, assets fs.FS, out, base string, paths []string, log *slog.Logger) error {
+	base = strings.TrimSuffix(base, "/")
+	e := &exporter{
+		handler:  handler,
+		base:     base,
+		absLinks: exportAbsoluteLinks(base),
+		log:      log,
+		pages:    map[string]*exportedPage{},
+	}
+
+	var queue []string
+	queue = append(queue, exportRoots...)
+	for _, l := range locales.Registry().Languages() {
+		for _, p := range append(exportLangRoots, paths...) {
+			queue = append(queue, p+"?lang="+url.QueryEscape(l.Tag))
+		}
+	}
+
+	for len(queue) > 0 {
+		uri := queue[0]
+		queue = queue[1:]
+
+		if _, ok := e.pages[uri]; ok {
+			continue
+		}
+		queue = append(queue, e.fetch(uri)...)
+	}
+
+	for _, uri := range e.order {
+		p := e.pages[uri]
+		p.file = exportFile(uri, p.header.Get("Content-Type"))
+	}
+
+	owners := e.owners()
+	for _, uri := range e.order {
+		p := e.pages[uri]
+		if owners[p.file] != p {
+			continue
+		}
+		if err := e.write(out, p); err != nil {
+			return err
+		}
+	}
+
+	index := exportRedirect("/" + locales.Fallback + "/")
+	if err := writeExportFile(filepath.Join(out, "index.html"), index); err != nil {
+		return err
+	}
+
+	return copyExportAssets(assets, filepath.Join(out, "assets"))
+}
+
+// fetch requests the page and returns the local pages it links or redirects to.
+func (e *exporter) fetch(uri string) []string {
+	e.log.Debug("Exporting page", slog.String("uri", uri))
+
+	res := &exportResponse{header: http.Header{}}
+	e.handler.ServeHTTP(res, httptest.NewRequest(http.MethodGet, uri, nil))
+	res.finish()
+
+	p := &exportedPage{
+		uri:    uri,
+		status: res.status,
+		header: res.header,
+		body:   res.body.Bytes(),
+	}
+
+	switch {
+	case p.status >= 300 && p.status < 400:
+		target, ok := exportURI(uri, p.header.Get("Location"))
+		if !ok {
+			e.log.Warn("Skipping redirect to a page that cannot be exported",
+				slog.String("uri", uri),
+				slog.String("location", p.header.Get("Location")),
+			)
+			return nil
+		}
+		p.redirect = target
+		e.add(p)
+		return []string{target}
+	case p.status != http.StatusOK:
+		e.log.Warn("Skipping page", slog.String("uri", uri), slog.Int("status", p.status))
+		return nil
+	}
+
+	e.add(p)
+
+	var links []string
+	for _, m := range e.absLinks.FindAllSubmatch(p.body, -1) {
+		if l, ok := exportURI(exportAbsoluteBase, html.UnescapeString(string(m[1]))); ok {
+			links = append(links, l)
+		}
+	}
+
+	if !isHTML(p.header.Get("Content-Type")) {
+		return links
+	}
+
+	for _, m := range exportLinks.FindAllSubmatch(p.body, -1) {
+		if l, ok := exportURI(uri, html.UnescapeString(string(m[2]))); ok {
+			links = append(links, l)
+		}
+	}
+	return links
+}
+
+// exportResponse records the response of a page. Unlike [httptest.ResponseRecorder],
+// which detects the content type from the first write only, it detects it from
+// the whole body, as servers do from their buffered output: templates are written
+// in many small writes, the first of which may be just white space.
+type exportResponse struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (r *exportResponse) Header() http.Header {
+	return r.header
+}
+
+func (r *exportResponse) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+}
+
+func (r *exportResponse) Write(b []byte) (int, error) {
+	r.WriteHeader(http.StatusOK)
+	return r.body.Write(b)
+}
+
+func (r *exportResponse) finish() {
+	r.WriteHeader(http.StatusOK)
+	if _, ok := r.header["Content-Type"]; !ok && r.body.Len() > 0 {
+		r.header.Set("Content-Type", http.DetectContentType(r.body.Bytes()))
+	}
+}
+
+func (e *exporter) add(p *exportedPage) {
+	e.pages[p.uri] = p
+	e.order = append(e.order, p.uri)
+}
+
+// owners returns the page written to each output file. Different URIs, such as
+// "/about?lang=en-US" redirecting to "/about/?lang=en-US", may map to the same
+// file: pages always take precedence over redirects, which are only written if
+// their target is another file, and otherwise the first page crawled wins.
+func (e *exporter) owners() map[string]*exportedPage {
+	owners := make(map[string]*exportedPage, len(e.order))
+	for _, uri := range e.order {
+		p := e.pages[uri]
+
+		if p.redirect != "" {
+			target, ok := e.pages[p.redirect]
+			if !ok || target.file == p.file {
+				continue
+			}
+			if _, taken := owners[p.file]; taken {
+				continue
+			}
+		} else if o, taken := owners[p.file]; taken && o.redirect == "" {
+			e.log.Warn("Skipping page written to the same file as another",
+				slog.String("uri", uri),
+				slog.String("file", p.file),
+				slog.String("owner", o.uri),
+			)
+			continue
+		}
+
+		owners[p.file] = p
+	}
+	return owners
+}
+
+func (e *exporter) write(out string, p *exportedPage) error {
+	file := filepath.Join(out, filepath.FromSlash(p.file))
+
+	if p.redirect != "" {
+		target, ok := e.pages[p.redirect]
+		if !ok {
+			return nil
+		}
+		return writeExportFile(file, exportRedirect(exportHref(target.file, "")))
+	}
+
+	body := e.absLinks.ReplaceAllFunc(p.body, func(m []byte) []byte {
+		ref := html.UnescapeString(strings.TrimPrefix(string(m), e.base))
+
+		uri, ok := exportURI(exportAbsoluteBase, ref)
+		if !ok {
+			return m
+		}
+		target, ok := e.pages[uri]
+		if !ok {
+			return m
+		}
+		return []byte(e.base + exportHref(target.file, exportFragment(ref)))
+	})
+	if isHTML(p.header.Get("Content-Type")) {
+		body = exportLinks.ReplaceAllFunc(body, func(attr []byte) []byte {
+			m := exportLinks.FindSubmatch(attr)
+			ref := html.UnescapeString(string(m[2]))
+
+			uri, ok := exportURI(p.uri, ref)
+			if !ok {
+				return attr
+			}
+			target, ok := e.pages[uri]
+			if !ok {
+				return attr
+			}
+			return fmt.Appendf(nil, `%s="%s"`, m[1], html.EscapeString(exportHref(target.file, exportFragment(ref))))
+		})
+	}
+
+	return writeExportFile(file, body)
+}
+
+// exportURI resolves the reference in the page at base and returns the request
+// URI of the local page it points to. Links to external sites, assets, pages
+// with query parameters other than "lang" and pages that depend on the server,
+// such as search, are not exported.
+func exportURI(base, ref string) (string, bool) {
+	if ref == "" || strings.HasPrefix(ref, "#") {
+		return "", false
+	}
+
+	b, err := url.Parse(base)
+	if err != nil {
+		return "", false
+	}
+	r, err := url.Parse(ref)
+	if err != nil {
+		return "", false
+	}
+
+	u := b.ResolveReference(r)
+	if u.Scheme != "" || u.Host != "" {
+		return "", false
+	}
+
+	switch {
+	case strings.HasPrefix(u.Path, "/assets/"),
+		strings.HasPrefix(u.Path, "/hooks/"),
+		strings.HasPrefix(u.Path, "/admin/"),
+		u.Path == "/blog/search":
+		return "", false
+	}
+
+	q := u.Query()
+	lang := q.Get("lang")
+	q.Del("lang")
+	if len(q) > 0 {
+		return "", false
+	}
+
+	if exportLangIndependent(u.Path) {
+		return u.Path, true
+	}
+	if lang == "" {
+		lang = b.Query().Get("lang")
+	}
+	if lang == "" {
+		return "", false
+	}
+	return u.Path + "?lang=" + url.QueryEscape(lang), true
+}
+
+func exportLangIndependent(p string) bool {
+	for _, r := range exportRoots {
+		if p == r {
+			return true
+		}
+	}
+	return strings.HasPrefix(p, "/.well-known/")
+}
+
+// exportFile returns the path of the file a page is written to, relative to the
+// output directory.
+func exportFile(uri, contentType string) string {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return strings.TrimPrefix(uri, "/")
+	}
+
+	p := u.Path
+	if lang := u.Query().Get("lang"); lang != "" {
+		p = "/" + lang + p
+	}
+	if isHTML(contentType) && path.Ext(p) != ".html" {
+		p = path.Join(p, "index.html")
+	}
+	return strings.TrimPrefix(path.Clean(p), "/")
+}
+
+// exportAbsoluteBase is the page absolute URLs are resolved from. URLs without a
+// language, such as the "x-default" alternates, point to the fallback language,
+// like the index of the export.
+var exportAbsoluteBase = "/?lang=" + url.QueryEscape(locales.Fallback)
+
+func exportFragment(ref string) string {
+	if u, err := url.Parse(ref); err == nil {
+		return u.Fragment
+	}
+	return ""
+}
+
+func exportHref(file, fragment string) string {
+	href := "/" + strings.TrimSuffix(file, "index.html")
+	if fragment != "" {
+		href += "#" + fragment
+	}
+	return href
+}
+
+func exportRedirect(target string) []byte {
+	t := html.EscapeString(target)
+	return fmt.Appendf(nil, "<!DOCTYPE html>\n"+
+		"<html><head><meta charset=\"utf-8\">"+
+		"<meta http-equiv=\"refresh\" content=\"0; url=%s\">"+
+		"<link rel=\"canonical\" href=\"%s\">"+
+		"</head><body><a href=\"%s\">%s</a></body></html>\n", t, t, t, t)
+}
+
+func isHTML(contentType string) bool {
+	t, _, _ := mime.ParseMediaType(contentType)
+	return t == "text/html"
+}
+
+func writeExportFile(file string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(file, data, 0o644)
+}
+
+func copyExportAssets(assets fs.FS, out string) error {
+	return fs.WalkDir(assets, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+
+		data, err := fs.ReadFile(assets, p)
+		if err != nil {
+			return err
+		}
+		return writeExportFile(filepath.Join(out, filepath.FromSlash(p)), data)
+	})
+}
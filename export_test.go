@@ -0,0 +1,112 @@
+package main
+
+import (
+	"io/fs"
+	"log/slog"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+// exportTree exports the site with the blog posts of source in all languages and
+// returns the content of the written files by their paths.
+func exportTree(t *testing.T, source fs.FS) map[string]string {
+	t.Helper()
+
+	opts := []Option{WithPages(), WithCacheDisabled(), WithSourceDates()}
+	for _, b := range DefaultBlogs {
+		opts = append(opts, WithBlogSource(b.Lang, source))
+	}
+	app, err := NewApp(opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := t.TempDir()
+	assets := fstest.MapFS{"stylesheets/out.css": {Data: []byte("body{}")}}
+	if err := exportSite(app, assets, out, "https://capytal.cc", nil, slog.New(slog.DiscardHandler)); err != nil {
+		t.Fatalf("exportSite() error = %v", err)
+	}
+
+	tree := map[string]string{}
+	err = filepath.WalkDir(out, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(out, p)
+		tree[filepath.ToSlash(rel)] = string(data)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tree
+}
+
+func TestExport(t *testing.T) {
+	source := func(modTime time.Time) fstest.MapFS {
+		return fstest.MapFS{
+			"hello.md": {
+				Data:    []byte("---\ntitle: Hello\ndate: 2025-01-02\nmodified: 2025-03-04\n---\n\nHello, world.\n"),
+				ModTime: modTime,
+			},
+			"undated.md": {Data: []byte("# Undated\n\nA post without a date.\n"), ModTime: modTime},
+			"draft.md":   {Data: []byte("---\ndate: 2025-01-01\ndraft: true\n---\n\n# Draft\n"), ModTime: modTime},
+		}
+	}
+
+	// The sources are checked out at different times for each export.
+	first := exportTree(t, source(time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)))
+	second := exportTree(t, source(time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC)))
+
+	for _, file := range []string{
+		"index.html",
+		"sitemap.xml",
+		"robots.txt",
+		"assets/stylesheets/out.css",
+		"en-US/index.html",
+		"pt-BR/index.html",
+		"en-US/blog/index.html",
+		"en-US/blog/feed.xml",
+		"en-US/blog/hello.md/index.html",
+		"pt-BR/blog/hello.md/index.html",
+		"en-US/blog/undated.md/index.html",
+	} {
+		if _, ok := first[file]; !ok {
+			t.Errorf("%s was not exported", file)
+		}
+	}
+	for file := range first {
+		if strings.Contains(file, "draft.md") {
+			t.Errorf("draft post was exported to %s", file)
+		}
+	}
+
+	// security.txt expires a year after the newest post, not after the export.
+	if s := first[".well-known/security.txt"]; !strings.Contains(s, "Expires: 2026-03-04T00:00:00Z\n") {
+		t.Errorf("security.txt does not expire a year after the newest post:\n%s", s)
+	}
+	// Only the post dated by its front matter has a publication date.
+	if feed := first["en-US/blog/feed.xml"]; strings.Count(feed, "<pubDate>") != 1 {
+		t.Errorf("undated post is dated by the modification time of its file:\n%s", feed)
+	}
+
+	files := slices.Sorted(maps.Keys(first))
+	if got := slices.Sorted(maps.Keys(second)); !slices.Equal(got, files) {
+		t.Fatalf("exports wrote different files:\n%v\n%v", files, got)
+	}
+	for _, file := range files {
+		if first[file] != second[file] {
+			t.Errorf("exports of %s differ:\n%s\n%s", file, first[file], second[file])
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"net/http"
+	"net/url"
+
+	"capytal.cc/internals/feed"
+)
+
+// feed serves the RSS, Atom or JSON feed of the blog, chosen by the file name of
+// the request path ("feed.xml", "atom.xml" or "feed.json" respectively).
+func (app *app) feed(b *blog) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ps, err := b.Posts(r.Context())
+		if err != nil {
+			app.serverError(w, r, err)
+			return
+		}
+
+		f := feed.Feed{
+			Title:    "Capytal Blog",
+			Language: b.lang,
+			Link:     app.blogURL(b.lang, ""),
+			Self:     app.blogURL(b.lang, r.URL.Path),
+			Author:   "Capytal",
+			Items:    make([]feed.Item, 0, len(ps)),
+		}
+
+		for _, p := range ps {
+			content, err := p.Render(app.markdown)
+			if err != nil {
+				app.serverError(w, r, err)
+				return
+			}
+
+			link := app.blogURL(b.lang, p.Name)
+			f.Items = append(f.Items, feed.Item{
+				ID:        link,
+				Title:     p.Title,
+				Link:      link,
+				Summary:   p.Description,
+				Content:   content,
+				Published: p.Date,
+				Updated:   p.Modified,
+			})
+
+			if p.Modified.After(f.Updated) {
+				f.Updated = p.Modified
+			}
+		}
+
+		switch r.URL.Path {
+		case "atom.xml":
+			w.Header().Set("Content-Type", feed.AtomContentType)
+			err = feed.WriteAtom(w, f)
+		case "feed.json":
+			w.Header().Set("Content-Type", feed.JSONContentType)
+			err = feed.WriteJSON(w, f)
+		default:
+			w.Header().Set("Content-Type", feed.RSSContentType)
+			err = feed.WriteRSS(w, f)
+		}
+		if err != nil {
+			app.serverError(w, r, err)
+			return
+		}
+	})
+}
+
+func (app *app) blogURL(lang, name string) string {
+	return app.pageURL("/blog/"+url.PathEscape(name), lang)
+}
@@ -18,5 +18,5 @@ require (
 	forge.capytal.company/loreddev/blogo v0.0.0-20250214135432-71f20192d450
 	github.com/yuin/goldmark v1.7.8
 	github.com/yuin/goldmark-meta v1.1.0
-	gopkg.in/yaml.v2 v2.3.0 // indirect
+	gopkg.in/yaml.v2 v2.3.0
 )
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"sync"
+	"time"
+)
+
+// readyTimeout is the deadline of all readiness checks.
+const readyTimeout = 5 * time.Second
+
+// healthCheck is a dependency of the application checked for readiness.
+type healthCheck struct {
+	name  string
+	check func(ctx context.Context) error
+}
+
+type checkResult struct {
+	Status  string  `json:"status"`
+	Latency float64 `json:"latency_ms"`
+	Error   string  `json:"error,omitempty"`
+}
+
+// healthz reports that the process is alive and serving requests.
+func (app *app) healthz() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		app.writeHealth(w, r, http.StatusOK, map[string]any{"status": "ok"})
+	})
+}
+
+// readyz reports if the application is ready to serve pages: its templates
+// parse, its assets are readable and the forges its content is read from are
+// reachable. Each check is reported with its status and latency, and the
+// response is 503 Service Unavailable if any fails.
+func (app *app) readyz() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), readyTimeout)
+		defer cancel()
+
+		checks := app.healthChecks()
+		results := make(map[string]checkResult, len(checks))
+
+		var mu sync.Mutex
+		var wg sync.WaitGroup
+		for _, c := range checks {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+
+				start := time.Now()
+				err := c.check(ctx)
+
+				res := checkResult{
+					Status:  "ok",
+					Latency: float64(time.Since(start).Microseconds()) / 1000,
+				}
+				if err != nil {
+					res.Status, res.Error = "fail", err.Error()
+					app.logger(ctx).Warn("Readiness check failed",
+						slog.String("check", c.name),
+						slog.String("error", err.Error()),
+					)
+				}
+
+				mu.Lock()
+				results[c.name] = res
+				mu.Unlock()
+			}()
+		}
+		wg.Wait()
+
+		status, code := "ok", http.StatusOK
+		for _, res := range results {
+			if res.Status != "ok" {
+				status, code = "fail", http.StatusServiceUnavailable
+			}
+		}
+
+		app.writeHealth(w, r, code, map[string]any{"status": status, "checks": results})
+	})
+}
+
+func (app *app) healthChecks() []healthCheck {
+	checks := []healthCheck{
+		{"templates", func(ctx context.Context) error {
+			// Probes are not pages, so their executions are not recorded in
+			// the templates metrics.
+			t := app.templates
+			if it, ok := t.(*instrumentedTemplates); ok {
+				t = it.ITemplate
+			}
+			// Executing a template parses them again if they are hot-reloaded.
+			return t.ExecuteTemplate(io.Discard, "layout-page-end", nil)
+		}},
+		{"assets", func(ctx context.Context) error {
+			_, err := fs.ReadDir(app.assets, ".")
+			return err
+		}},
+	}
+
+	for _, f := range app.forges() {
+		checks = append(checks, healthCheck{"forge:" + f.Host, func(ctx context.Context) error {
+			return pingForge(ctx, f)
+		}})
+	}
+
+	return checks
+}
+
+// forges returns the base URLs of the forges the blogs and remote pages are read
+// from, without duplicates.
+func (app *app) forges() []*url.URL {
+	var l []*url.URL
+	add := func(raw string) {
+		u, err := url.Parse(raw)
+		if err != nil || u.Host == "" {
+			return
+		}
+		if slices.ContainsFunc(l, func(f *url.URL) bool { return f.Host == u.Host }) {
+			return
+		}
+		l = append(l, &url.URL{Scheme: u.Scheme, Host: u.Host})
+	}
+
+	for _, c := range app.blogConfigs {
+		if _, local := app.blogSources[c.Lang]; !local {
+			add(c.Forge)
+		}
+	}
+	for _, c := range app.pageConfigs {
+		if c.remote() {
+			add(c.Source)
+		}
+	}
+
+	return l
+}
+
+// forgeClient propagates the trace of readiness checks to the forges.
+var forgeClient = &http.Client{Transport: tracingTransport(http.DefaultTransport)}
+
+// pingForge requests the version endpoint of the Gitea/Forgejo API of the forge.
+func pingForge(ctx context.Context, forge *url.URL) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, forge.JoinPath("/api/v1/version").String(), nil)
+	if err != nil {
+		return err
+	}
+
+	res, err := forgeClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	_, _ = io.Copy(io.Discard, res.Body)
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d", res.StatusCode)
+	}
+	return nil
+}
+
+func (app *app) writeHealth(w http.ResponseWriter, r *http.Request, code int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		app.logger(r.Context()).Error("Failed to write health response", slog.String("error", err.Error()))
+	}
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"capytal.cc/locales"
+)
+
+// maxWebhookSize is the maximum size in bytes of a webhook payload.
+const maxWebhookSize = 5 << 20
+
+// giteaPush is the subset of the payload of a Gitea/Forgejo push event used to
+// know which content changed.
+type giteaPush struct {
+	Ref        string `json:"ref"`
+	Repository struct {
+		FullName      string `json:"full_name"`
+		DefaultBranch string `json:"default_branch"`
+	} `json:"repository"`
+}
+
+// giteaHook receives the push events of the repositories of the blogs and
+// markdown pages, so their content is refreshed as soon as it changes instead
+// of after their caches expire. Payloads must be signed with the webhook secret,
+// and the endpoint is disabled if there is none.
+func (app *app) giteaHook() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(app.webhookSecret) == 0 {
+			app.notFound(w, r)
+			return
+		}
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			app.fail(w, r, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxWebhookSize))
+		if err != nil {
+			app.fail(w, r, http.StatusBadRequest, errors.New("unable to read payload"))
+			return
+		}
+
+		if !verifyGiteaSignature(app.webhookSecret, body, r.Header.Get("X-Gitea-Signature")) {
+			app.fail(w, r, http.StatusUnauthorized, errors.New("invalid signature"))
+			return
+		}
+
+		if e := r.Header.Get("X-Gitea-Event"); e != "push" {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		var push giteaPush
+		if err := json.Unmarshal(body, &push); err != nil {
+			app.fail(w, r, http.StatusBadRequest, errors.New("invalid payload"))
+			return
+		}
+
+		app.logger(r.Context()).Info("Received push webhook",
+			slog.String("repository", push.Repository.FullName),
+			slog.String("ref", push.Ref),
+		)
+
+		app.refresh(r.Context(), push)
+
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+// refresh discards the cached content and rendered responses of the blogs and
+// pages sourced from the pushed repository and branch. Data derived from the
+// blogs' posts, such as the search indexes, feeds and sitemap, are regenerated
+// once the new posts are loaded.
+func (app *app) refresh(ctx context.Context, push giteaPush) {
+	branch, ok := strings.CutPrefix(push.Ref, "refs/heads/")
+	if !ok {
+		return
+	}
+
+	for i, c := range app.blogConfigs {
+		if _, local := app.blogSources[c.Lang]; local {
+			continue
+		}
+		if !strings.EqualFold(c.Owner+"/"+c.Repo, push.Repository.FullName) {
+			continue
+		}
+
+		ref := c.Ref
+		if ref == "" {
+			ref = push.Repository.DefaultBranch
+		}
+		if ref != branch {
+			continue
+		}
+
+		app.logger(ctx).Debug("Refreshing blog", slog.String("lang", c.Lang))
+		app.blogs[i].Refresh()
+		if app.responses != nil {
+			app.responses.PurgeTag("blog")
+		}
+	}
+
+	// Raw URLs of remote pages may not specify their ref, so all branches of
+	// their repository invalidate them.
+	repo := "/repos/" + strings.ToLower(push.Repository.FullName) + "/"
+	for _, c := range app.pageConfigs {
+		if !c.remote() || !strings.Contains(strings.ToLower(c.Source), repo) {
+			continue
+		}
+
+		app.logger(ctx).Debug("Refreshing page", slog.String("path", c.Path))
+		app.remote.Invalidate(c.Source)
+		for _, l := range locales.Registry().Languages() {
+			app.remote.Invalidate(c.localized(l.Tag))
+		}
+		if app.responses != nil {
+			app.responses.Purge(c.Path)
+		}
+	}
+}
+
+// verifyGiteaSignature reports if the signature is the hex encoded HMAC-SHA256
+// of the body using the secret, as sent by Gitea in the X-Gitea-Signature header.
+func verifyGiteaSignature(secret, body []byte, signature string) bool {
+	sig, err := hex.DecodeString(signature)
+	if err != nil || len(sig) != sha256.Size {
+		return false
+	}
+
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return hmac.Equal(sig, mac.Sum(nil))
+}
@@ -0,0 +1,228 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"capytal.cc/internals/httpcache"
+	"capytal.cc/internals/posts"
+	"capytal.cc/internals/remote"
+)
+
+var testWebhookSecret = []byte("webhook secret")
+
+func sign(secret []byte, body string) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(body))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestVerifyGiteaSignature(t *testing.T) {
+	body := `{"ref":"refs/heads/main"}`
+	good := sign(testWebhookSecret, body)
+
+	tests := []struct {
+		name      string
+		signature string
+		want      bool
+	}{
+		{"good", good, true},
+		{"missing", "", false},
+		{"other secret", sign([]byte("other secret"), body), false},
+		{"other body", sign(testWebhookSecret, body+" "), false},
+		{"truncated", good[:len(good)-2], false},
+		{"upper case", strings.ToUpper(good), true},
+		{"not hexadecimal", "z" + good[1:], false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := verifyGiteaSignature(testWebhookSecret, []byte(body), tt.signature); got != tt.want {
+				t.Errorf("verifyGiteaSignature(%q) = %v, want %v", tt.signature, got, tt.want)
+			}
+		})
+	}
+}
+
+// newHookTestApp returns an app with the default blogs, whose posts are loaded,
+// and a response cache with a blog post and a page.
+func newHookTestApp(t *testing.T) *app {
+	t.Helper()
+
+	app := &app{
+		log:           slog.New(slog.DiscardHandler),
+		webhookSecret: testWebhookSecret,
+		blogConfigs:   DefaultBlogs,
+		pageConfigs: []PageConfig{
+			{Path: "/about/", Source: DefaultForge + "/api/v1/repos/capytal/capytal.cc/raw/ABOUT.md"},
+		},
+		remote:    remote.New(),
+		responses: httpcache.New(),
+	}
+	for _, c := range app.blogConfigs {
+		b := &blog{lang: c.Lang}
+		b.posts.all = []*posts.Post{}
+		app.blogs = append(app.blogs, b)
+	}
+
+	h := app.responses.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/blog/") {
+			w.Header().Set(httpcache.TagHeader, "blog")
+		}
+		_, _ = w.Write([]byte("response"))
+	}))
+	for _, path := range []string{"/blog/post/", "/about/"} {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+	if n := app.responses.Len(); n != 2 {
+		t.Fatalf("cached %d responses, want 2", n)
+	}
+
+	return app
+}
+
+func pushRequest(body, signature string) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, "/hooks/gitea", strings.NewReader(body))
+	r.Header.Set("Accept", "application/json")
+	r.Header.Set("X-Gitea-Event", "push")
+	if signature != "" {
+		r.Header.Set("X-Gitea-Signature", signature)
+	}
+	return r
+}
+
+func TestGiteaHook(t *testing.T) {
+	push := func(repo, ref string) string {
+		return `{"ref":"` + ref + `","repository":{"full_name":"` + repo + `","default_branch":"main"}}`
+	}
+	blogPush := push("capytal/capytal.cc-blog", "refs/heads/main-pt")
+
+	tests := []struct {
+		name string
+		body string
+		// signature defaults to the signature of the body with the secret,
+		// unless the request is unsigned.
+		signature string
+		unsigned  bool
+		status    int
+		// refreshed are the languages of the blogs expected to be refreshed.
+		refreshed []string
+		// cached are the paths expected to still be cached.
+		cached []string
+	}{
+		{
+			name:     "missing signature",
+			body:     blogPush,
+			unsigned: true,
+			status:   http.StatusUnauthorized,
+			cached:   []string{"/blog/post/", "/about/"},
+		},
+		{
+			name:      "bad signature",
+			body:      blogPush,
+			signature: sign([]byte("other secret"), blogPush),
+			status:    http.StatusUnauthorized,
+			cached:    []string{"/blog/post/", "/about/"},
+		},
+		{
+			name:   "other repository",
+			body:   push("capytal/other", "refs/heads/main"),
+			status: http.StatusNoContent,
+			cached: []string{"/blog/post/", "/about/"},
+		},
+		{
+			name:   "other branch",
+			body:   push("capytal/capytal.cc-blog", "refs/heads/dev"),
+			status: http.StatusNoContent,
+			cached: []string{"/blog/post/", "/about/"},
+		},
+		{
+			name:   "tag",
+			body:   push("capytal/capytal.cc-blog", "refs/tags/main"),
+			status: http.StatusNoContent,
+			cached: []string{"/blog/post/", "/about/"},
+		},
+		{
+			name:      "blog ref",
+			body:      blogPush,
+			status:    http.StatusNoContent,
+			refreshed: []string{"pt-BR"},
+			cached:    []string{"/about/"},
+		},
+		{
+			name:      "blog default branch",
+			body:      push("Capytal/Capytal.cc-Blog", "refs/heads/main"),
+			status:    http.StatusNoContent,
+			refreshed: []string{"en-US"},
+			cached:    []string{"/about/"},
+		},
+		{
+			name:   "page repository",
+			body:   push("capytal/capytal.cc", "refs/heads/dev"),
+			status: http.StatusNoContent,
+			cached: []string{"/blog/post/"},
+		},
+		{
+			name:   "invalid payload",
+			body:   `{"ref":`,
+			status: http.StatusBadRequest,
+			cached: []string{"/blog/post/", "/about/"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := newHookTestApp(t)
+
+			signature := tt.signature
+			if signature == "" && !tt.unsigned {
+				signature = sign(testWebhookSecret, tt.body)
+			}
+
+			w := httptest.NewRecorder()
+			app.giteaHook().ServeHTTP(w, pushRequest(tt.body, signature))
+
+			if w.Code != tt.status {
+				t.Errorf("status = %d, want %d", w.Code, tt.status)
+			}
+
+			for i, b := range app.blogs {
+				want := strings.Contains(strings.Join(tt.refreshed, " "), app.blogConfigs[i].Lang)
+				if refreshed := b.posts.all == nil; refreshed != want {
+					t.Errorf("blog %s refreshed = %v, want %v", b.lang, refreshed, want)
+				}
+			}
+
+			if n := app.responses.Len(); n != len(tt.cached) {
+				t.Errorf("%d responses are cached, want %d", n, len(tt.cached))
+			}
+			for _, path := range tt.cached {
+				if app.responses.Purge(path) != 1 {
+					t.Errorf("response of %s is not cached", path)
+				}
+			}
+		})
+	}
+}
+
+func TestGiteaHookDisabled(t *testing.T) {
+	app := newHookTestApp(t)
+	app.webhookSecret = nil
+
+	body := `{"ref":"refs/heads/main","repository":{"full_name":"capytal/capytal.cc-blog","default_branch":"main"}}`
+	w := httptest.NewRecorder()
+	app.giteaHook().ServeHTTP(w, pushRequest(body, sign(nil, body)))
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+	if app.blogs[0].posts.all == nil {
+		t.Error("blog was refreshed")
+	}
+}
@@ -0,0 +1,288 @@
+// Package feed encodes syndication feeds in the RSS 2.0, Atom 1.0 and JSON Feed
+// 1.1 formats.
+package feed
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"time"
+)
+
+// Feed is the format-independent representation of a syndication feed.
+type Feed struct {
+	Title       string
+	Description string
+	Language    string
+	// Link is the absolute URL of the HTML page the feed represents.
+	Link string
+	// Self is the absolute URL of the feed itself.
+	Self    string
+	Author  string
+	Updated time.Time
+	Items   []Item
+}
+
+// Item is a single entry of a [Feed].
+type Item struct {
+	// ID is a permanent unique identifier of the item, normally its absolute URL.
+	ID        string
+	Title     string
+	Link      string
+	Summary   string
+	Content   string
+	Published time.Time
+	Updated   time.Time
+}
+
+// Content types of each feed format.
+const (
+	RSSContentType  = "application/rss+xml; charset=utf-8"
+	AtomContentType = "application/atom+xml; charset=utf-8"
+	JSONContentType = "application/feed+json; charset=utf-8"
+)
+
+type rss struct {
+	XMLName   xml.Name   `xml:"rss"`
+	Version   string     `xml:"version,attr"`
+	ContentNS string     `xml:"xmlns:content,attr"`
+	AtomNS    string     `xml:"xmlns:atom,attr"`
+	Channel   rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Self          rssSelf   `xml:"atom:link"`
+	Description   string    `xml:"description"`
+	Language      string    `xml:"language,omitempty"`
+	LastBuildDate string    `xml:"lastBuildDate,omitempty"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssSelf struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr"`
+	Type string `xml:"type,attr"`
+}
+
+type rssItem struct {
+	Title       string  `xml:"title"`
+	Link        string  `xml:"link"`
+	GUID        rssGUID `xml:"guid"`
+	PubDate     string  `xml:"pubDate,omitempty"`
+	Description string  `xml:"description,omitempty"`
+	Content     *cdata  `xml:"content:encoded,omitempty"`
+}
+
+type rssGUID struct {
+	Value       string `xml:",chardata"`
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+}
+
+type cdata struct {
+	Value string `xml:",cdata"`
+}
+
+// WriteRSS encodes the feed as a RSS 2.0 document.
+func WriteRSS(w io.Writer, f Feed) error {
+	doc := rss{
+		Version:   "2.0",
+		ContentNS: "http://purl.org/rss/1.0/modules/content/",
+		AtomNS:    "http://www.w3.org/2005/Atom",
+		Channel: rssChannel{
+			Title:       f.Title,
+			Link:        f.Link,
+			Self:        rssSelf{Href: f.Self, Rel: "self", Type: "application/rss+xml"},
+			Description: f.Description,
+			Language:    f.Language,
+			Items:       make([]rssItem, 0, len(f.Items)),
+		},
+	}
+	if !f.Updated.IsZero() {
+		doc.Channel.LastBuildDate = f.Updated.Format(time.RFC1123Z)
+	}
+
+	for _, i := range f.Items {
+		item := rssItem{
+			Title:       i.Title,
+			Link:        i.Link,
+			GUID:        rssGUID{Value: i.ID, IsPermaLink: i.ID == i.Link},
+			Description: i.Summary,
+		}
+		if i.Content != "" {
+			item.Content = &cdata{i.Content}
+		}
+		if !i.Published.IsZero() {
+			item.PubDate = i.Published.Format(time.RFC1123Z)
+		}
+		doc.Channel.Items = append(doc.Channel.Items, item)
+	}
+
+	return writeXML(w, doc)
+}
+
+type atom struct {
+	XMLName  xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Lang     string      `xml:"xml:lang,attr,omitempty"`
+	ID       string      `xml:"id"`
+	Title    string      `xml:"title"`
+	Subtitle string      `xml:"subtitle,omitempty"`
+	Updated  string      `xml:"updated"`
+	Links    []atomLink  `xml:"link"`
+	Author   *atomAuthor `xml:"author,omitempty"`
+	Entries  []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomText struct {
+	Type  string `xml:"type,attr"`
+	Value string `xml:",chardata"`
+}
+
+type atomEntry struct {
+	ID        string    `xml:"id"`
+	Title     string    `xml:"title"`
+	Link      atomLink  `xml:"link"`
+	Published string    `xml:"published,omitempty"`
+	Updated   string    `xml:"updated"`
+	Summary   *atomText `xml:"summary,omitempty"`
+	Content   *atomText `xml:"content,omitempty"`
+}
+
+// WriteAtom encodes the feed as an Atom 1.0 document.
+func WriteAtom(w io.Writer, f Feed) error {
+	doc := atom{
+		Lang:     f.Language,
+		ID:       f.Self,
+		Title:    f.Title,
+		Subtitle: f.Description,
+		Updated:  atomTime(f.Updated),
+		Links: []atomLink{
+			{Href: f.Link, Rel: "alternate", Type: "text/html"},
+			{Href: f.Self, Rel: "self", Type: "application/atom+xml"},
+		},
+		Entries: make([]atomEntry, 0, len(f.Items)),
+	}
+	if f.Author != "" {
+		doc.Author = &atomAuthor{Name: f.Author}
+	}
+
+	for _, i := range f.Items {
+		updated := i.Updated
+		if updated.IsZero() {
+			updated = i.Published
+		}
+
+		entry := atomEntry{
+			ID:      i.ID,
+			Title:   i.Title,
+			Link:    atomLink{Href: i.Link, Rel: "alternate", Type: "text/html"},
+			Updated: atomTime(updated),
+		}
+		if !i.Published.IsZero() {
+			entry.Published = atomTime(i.Published)
+		}
+		if i.Summary != "" {
+			entry.Summary = &atomText{Type: "text", Value: i.Summary}
+		}
+		if i.Content != "" {
+			entry.Content = &atomText{Type: "html", Value: i.Content}
+		}
+		doc.Entries = append(doc.Entries, entry)
+	}
+
+	return writeXML(w, doc)
+}
+
+type jsonFeed struct {
+	Version     string           `json:"version"`
+	Title       string           `json:"title"`
+	HomePageURL string           `json:"home_page_url,omitempty"`
+	FeedURL     string           `json:"feed_url,omitempty"`
+	Description string           `json:"description,omitempty"`
+	Language    string           `json:"language,omitempty"`
+	Authors     []jsonFeedAuthor `json:"authors,omitempty"`
+	Items       []jsonFeedItem   `json:"items"`
+}
+
+type jsonFeedAuthor struct {
+	Name string `json:"name"`
+}
+
+type jsonFeedItem struct {
+	ID            string `json:"id"`
+	URL           string `json:"url,omitempty"`
+	Title         string `json:"title,omitempty"`
+	Summary       string `json:"summary,omitempty"`
+	ContentHTML   string `json:"content_html,omitempty"`
+	DatePublished string `json:"date_published,omitempty"`
+	DateModified  string `json:"date_modified,omitempty"`
+}
+
+// WriteJSON encodes the feed as a JSON Feed 1.1 document.
+func WriteJSON(w io.Writer, f Feed) error {
+	doc := jsonFeed{
+		Version:     "https://jsonfeed.org/version/1.1",
+		Title:       f.Title,
+		HomePageURL: f.Link,
+		FeedURL:     f.Self,
+		Description: f.Description,
+		Language:    f.Language,
+		Items:       make([]jsonFeedItem, 0, len(f.Items)),
+	}
+	if f.Author != "" {
+		doc.Authors = []jsonFeedAuthor{{Name: f.Author}}
+	}
+
+	for _, i := range f.Items {
+		item := jsonFeedItem{
+			ID:          i.ID,
+			URL:         i.Link,
+			Title:       i.Title,
+			Summary:     i.Summary,
+			ContentHTML: i.Content,
+		}
+		if !i.Published.IsZero() {
+			item.DatePublished = i.Published.Format(time.RFC3339)
+		}
+		if !i.Updated.IsZero() {
+			item.DateModified = i.Updated.Format(time.RFC3339)
+		}
+		doc.Items = append(doc.Items, item)
+	}
+
+	e := json.NewEncoder(w)
+	e.SetIndent("", "\t")
+	return e.Encode(doc)
+}
+
+func writeXML(w io.Writer, doc any) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	e := xml.NewEncoder(w)
+	e.Indent("", "\t")
+	if err := e.Encode(doc); err != nil {
+		return err
+	}
+
+	return e.Close()
+}
+
+func atomTime(t time.Time) string {
+	if t.IsZero() {
+		t = time.Unix(0, 0)
+	}
+	return t.UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,383 @@
+// Package httpcache implements an in-memory cache of full HTTP responses, so
+// pages are not rendered again on every request.
+package httpcache
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// TagHeader is the response header handlers use to tag cached responses, as a
+// space-separated list of tags, so they can be purged together with [Cache.PurgeTag].
+// The header is not sent to clients.
+const TagHeader = "Surrogate-Key"
+
+// StatusHeader is the response header that reports if a response was served
+// from the cache: "HIT", "STALE" or "MISS".
+const StatusHeader = "X-Cache"
+
+// varyHeaders are the request headers that are part of the cache key. Responses
+// varying by any other header are not cached.
+var varyHeaders = []string{"Accept", "HX-Request", "HX-Boosted"}
+
+// keyParams are the query parameters that are part of the cache key. Other
+// parameters, such as the ones added by trackers, don't change the response and
+// are ignored, so they can't be used to fill the cache with copies of it.
+var keyParams = []string{"lang", "q", "page", "preview"}
+
+// Cache is a size-bounded LRU cache of responses. Responses are fresh for the
+// cache's TTL and, after that, served stale while they are revalidated in the
+// background for the stale duration.
+type Cache struct {
+	maxSize int
+	ttl     time.Duration
+	stale   time.Duration
+	log     *slog.Logger
+
+	mu      sync.Mutex
+	size    int
+	lru     *list.List
+	entries map[string]*list.Element
+
+	hits, stales, misses atomic.Uint64
+}
+
+// Stats are the number of requests served by a cache since it was created.
+type Stats struct {
+	// Hits are requests served with fresh responses.
+	Hits uint64
+	// Stale are requests served with stale responses being revalidated.
+	Stale uint64
+	// Misses are requests passed to the handler.
+	Misses uint64
+}
+
+type entry struct {
+	key  string
+	path string
+	tags []string
+
+	status int
+	header http.Header
+	body   []byte
+
+	stored       time.Time
+	revalidating bool
+}
+
+func (e *entry) size() int {
+	return len(e.key) + len(e.body)
+}
+
+type Option func(c *Cache)
+
+// WithMaxSize sets the maximum size in bytes of the cached response bodies.
+// Defaults to 64 MiB.
+func WithMaxSize(bytes int) Option {
+	return func(c *Cache) { c.maxSize = bytes }
+}
+
+// WithTTL sets how long responses are fresh. Defaults to 5 minutes.
+func WithTTL(d time.Duration) Option {
+	return func(c *Cache) { c.ttl = d }
+}
+
+// WithStale sets how long, after they stop being fresh, responses are served
+// while being revalidated. Defaults to 1 hour.
+func WithStale(d time.Duration) Option {
+	return func(c *Cache) { c.stale = d }
+}
+
+// WithLogger sets the logger used to report failed revalidations.
+func WithLogger(l *slog.Logger) Option {
+	return func(c *Cache) { c.log = l }
+}
+
+// New creates an empty cache.
+func New(opts ...Option) *Cache {
+	c := &Cache{
+		maxSize: 64 << 20,
+		ttl:     5 * time.Minute,
+		stale:   time.Hour,
+		log:     slog.New(slog.DiscardHandler),
+		lru:     list.New(),
+		entries: map[string]*list.Element{},
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Middleware caches the successful responses of GET and HEAD requests of the
+// handler. Responses setting cookies, marked as private or no-store, or varying
+// by headers other than the ones in the cache key are not cached. Responses must
+// not depend on query parameters other than the ones in the cache key.
+func (c *Cache) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		key := cacheKey(r)
+
+		if e, fresh, ok := c.get(key); ok {
+			status := "HIT"
+			if fresh {
+				c.hits.Add(1)
+			} else {
+				status = "STALE"
+				c.stales.Add(1)
+				c.revalidate(e, next, r)
+			}
+			c.write(w, r, e, status)
+			return
+		}
+
+		c.misses.Add(1)
+
+		e := record(next, r)
+		e.key = key
+		e.path = r.URL.Path
+		// Responses of HEAD requests have no body to be reused by GET requests.
+		if r.Method == http.MethodGet && cacheable(e) {
+			c.set(e)
+		}
+		c.write(w, r, e, "MISS")
+	})
+}
+
+// Purge removes the cached responses of the paths starting with the prefix,
+// returning how many were removed. An empty prefix purges the whole cache.
+func (c *Cache) Purge(prefix string) int {
+	return c.purge(func(e *entry) bool { return strings.HasPrefix(e.path, prefix) })
+}
+
+// PurgeTag removes the cached responses tagged with the tag, returning how many
+// were removed.
+func (c *Cache) PurgeTag(tag string) int {
+	return c.purge(func(e *entry) bool { return slices.Contains(e.tags, tag) })
+}
+
+// Stats returns the number of hits and misses of the cache.
+func (c *Cache) Stats() Stats {
+	return Stats{
+		Hits:   c.hits.Load(),
+		Stale:  c.stales.Load(),
+		Misses: c.misses.Load(),
+	}
+}
+
+// Len returns the number of cached responses.
+func (c *Cache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.lru.Len()
+}
+
+func (c *Cache) purge(match func(e *entry) bool) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	n := 0
+	for el := c.lru.Front(); el != nil; {
+		next := el.Next()
+		if match(el.Value.(*entry)) {
+			c.remove(el)
+			n++
+		}
+		el = next
+	}
+	return n
+}
+
+func (c *Cache) get(key string) (e *entry, fresh bool, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.entries[key]
+	if !ok {
+		return nil, false, false
+	}
+	e = el.Value.(*entry)
+
+	age := time.Since(e.stored)
+	if age >= c.ttl+c.stale {
+		c.remove(el)
+		return nil, false, false
+	}
+
+	c.lru.MoveToFront(el)
+	return e, age < c.ttl, true
+}
+
+func (c *Cache) set(e *entry) {
+	if e.size() > c.maxSize {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.entries[e.key]; ok {
+		c.remove(el)
+	}
+
+	c.entries[e.key] = c.lru.PushFront(e)
+	c.size += e.size()
+
+	for c.size > c.maxSize {
+		c.remove(c.lru.Back())
+	}
+}
+
+func (c *Cache) remove(el *list.Element) {
+	e := c.lru.Remove(el).(*entry)
+	delete(c.entries, e.key)
+	c.size -= e.size()
+}
+
+// revalidate renders the response of the stale entry again in the background,
+// replacing it if it is still cacheable or removing it otherwise.
+func (c *Cache) revalidate(stale *entry, next http.Handler, r *http.Request) {
+	c.mu.Lock()
+	if stale.revalidating {
+		c.mu.Unlock()
+		return
+	}
+	stale.revalidating = true
+	c.mu.Unlock()
+
+	r = r.Clone(context.WithoutCancel(r.Context()))
+	r.Method = http.MethodGet
+
+	go func() {
+		e := record(next, r)
+		e.key, e.path = stale.key, stale.path
+
+		if cacheable(e) {
+			c.set(e)
+			return
+		}
+
+		c.log.Warn("Failed to revalidate cached response",
+			slog.String("path", stale.path),
+			slog.Int("status", e.status),
+		)
+
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		if el, ok := c.entries[stale.key]; ok && el.Value == stale {
+			c.remove(el)
+		}
+	}()
+}
+
+func (c *Cache) write(w http.ResponseWriter, r *http.Request, e *entry, status string) {
+	h := w.Header()
+	for k, v := range e.header {
+		h[k] = slices.Clone(v)
+	}
+	h.Del(TagHeader)
+	h.Set(StatusHeader, status)
+
+	w.WriteHeader(e.status)
+	if r.Method != http.MethodHead {
+		_, _ = w.Write(e.body)
+	}
+}
+
+// recorder is a [http.ResponseWriter] that buffers the response.
+type recorder struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (rec *recorder) Header() http.Header {
+	return rec.header
+}
+
+func (rec *recorder) WriteHeader(status int) {
+	if rec.status == 0 {
+		rec.status = status
+	}
+}
+
+func (rec *recorder) Write(b []byte) (int, error) {
+	rec.WriteHeader(http.StatusOK)
+	return rec.body.Write(b)
+}
+
+func record(next http.Handler, r *http.Request) *entry {
+	rec := &recorder{header: http.Header{}}
+	next.ServeHTTP(rec, r)
+
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+
+	return &entry{
+		tags:   strings.Fields(rec.header.Get(TagHeader)),
+		status: rec.status,
+		header: rec.header,
+		body:   rec.body.Bytes(),
+		stored: time.Now(),
+	}
+}
+
+func cacheable(e *entry) bool {
+	if e.status != http.StatusOK || e.header.Get("Set-Cookie") != "" {
+		return false
+	}
+
+	for _, v := range e.header.Values("Cache-Control") {
+		for _, d := range strings.Split(v, ",") {
+			switch strings.ToLower(strings.TrimSpace(d)) {
+			case "private", "no-store":
+				return false
+			}
+		}
+	}
+
+	for _, v := range e.header.Values("Vary") {
+		for _, h := range strings.Split(v, ",") {
+			h = strings.TrimSpace(h)
+			if !slices.ContainsFunc(varyHeaders, func(v string) bool { return strings.EqualFold(v, h) }) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+func cacheKey(r *http.Request) string {
+	query, params := r.URL.Query(), url.Values{}
+	for _, p := range keyParams {
+		if v, ok := query[p]; ok {
+			params[p] = v
+		}
+	}
+
+	b := new(strings.Builder)
+	b.WriteString(r.URL.Path)
+	b.WriteByte('?')
+	b.WriteString(params.Encode())
+	for _, h := range varyHeaders {
+		b.WriteByte('\x00')
+		b.WriteString(r.Header.Get(h))
+	}
+	return b.String()
+}
@@ -0,0 +1,52 @@
+package httpcache
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCacheKeyQuery(t *testing.T) {
+	c := New()
+	var calls int
+	h := c.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		_, _ = w.Write([]byte(r.URL.RawQuery))
+	}))
+
+	tests := []struct {
+		target string
+		status string
+	}{
+		{"/blog/?lang=en-US", "MISS"},
+		{"/blog/?lang=en-US&utm_source=feed", "HIT"},
+		{"/blog/?fbclid=abc&lang=en-US", "HIT"},
+		{"/blog/?lang=pt-BR", "MISS"},
+		{"/blog/?q=go&lang=en-US", "MISS"},
+		{"/blog/?lang=en-US&q=go&ref=x", "HIT"},
+		{"/blog/?lang=en-US&page=2", "MISS"},
+		{"/blog/?lang=en-US&preview=token", "MISS"},
+		{"/blog/", "MISS"},
+		{"/blog/?utm_source=feed", "HIT"},
+	}
+
+	misses := 0
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.target, nil))
+
+		if got := w.Header().Get(StatusHeader); got != tt.status {
+			t.Errorf("%s: %s = %q, want %q", tt.target, StatusHeader, got, tt.status)
+		}
+		if tt.status == "MISS" {
+			misses++
+		}
+	}
+
+	if calls != misses {
+		t.Errorf("handler called %d times, want %d", calls, misses)
+	}
+	if n := c.Len(); n != misses {
+		t.Errorf("cached %d responses, want %d", n, misses)
+	}
+}
@@ -0,0 +1,149 @@
+// Package i18n implements a registry of the supported languages and their
+// message catalogs.
+package i18n
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+)
+
+// Language is a supported language of the application.
+type Language struct {
+	// Tag is the BCP 47 language tag, e.g. "pt-BR".
+	Tag string `json:"tag"`
+	// Name is the name of the language in the language itself, e.g. "Português".
+	Name string `json:"name"`
+}
+
+// Catalog is the file format of a language's messages.
+type Catalog struct {
+	Language
+	Messages map[string]string `json:"messages"`
+}
+
+// Registry holds the supported languages and their message catalogs.
+type Registry struct {
+	fallback  string
+	languages []Language
+	catalogs  map[string]map[string]string
+}
+
+// Load reads all "*.json" catalogs in the root of fsys. The fallback language is
+// used for languages and messages without a translation, and must be one of the
+// loaded catalogs.
+func Load(fsys fs.FS, fallback string) (*Registry, error) {
+	files, err := fs.Glob(fsys, "*.json")
+	if err != nil {
+		return nil, err
+	}
+
+	catalogs := make([]Catalog, 0, len(files))
+	for _, f := range files {
+		c, err := fs.ReadFile(fsys, f)
+		if err != nil {
+			return nil, err
+		}
+
+		var catalog Catalog
+		if err := json.Unmarshal(c, &catalog); err != nil {
+			return nil, fmt.Errorf("invalid catalog %q: %w", f, err)
+		}
+		if catalog.Tag == "" {
+			catalog.Tag = strings.TrimSuffix(f, path.Ext(f))
+		}
+
+		catalogs = append(catalogs, catalog)
+	}
+
+	return New(fallback, catalogs...)
+}
+
+// New creates a registry from the catalogs. See [Load] for the fallback language.
+func New(fallback string, catalogs ...Catalog) (*Registry, error) {
+	r := &Registry{
+		catalogs: make(map[string]map[string]string, len(catalogs)),
+	}
+
+	for _, c := range catalogs {
+		k := strings.ToLower(c.Tag)
+		if _, ok := r.catalogs[k]; ok {
+			return nil, fmt.Errorf("duplicated catalog for language %q", c.Tag)
+		}
+		if c.Name == "" {
+			c.Name = c.Tag
+		}
+
+		r.catalogs[k] = c.Messages
+		r.languages = append(r.languages, c.Language)
+
+		if strings.EqualFold(c.Tag, fallback) {
+			r.fallback = c.Tag
+		}
+	}
+
+	if r.fallback == "" {
+		return nil, errors.New("fallback language does not have a catalog")
+	}
+
+	// The fallback language is always listed first, followed by the others in
+	// alphabetical order of their tags.
+	sort.SliceStable(r.languages, func(i, j int) bool {
+		if r.languages[i].Tag == r.fallback || r.languages[j].Tag == r.fallback {
+			return r.languages[i].Tag == r.fallback
+		}
+		return r.languages[i].Tag < r.languages[j].Tag
+	})
+
+	return r, nil
+}
+
+// Languages returns all supported languages, with the fallback language first.
+func (r *Registry) Languages() []Language {
+	return r.languages
+}
+
+// Fallback returns the language used when no other is supported.
+func (r *Registry) Fallback() Language {
+	return r.languages[0]
+}
+
+// Lookup returns the supported language with the tag, compared case-insensitively.
+func (r *Registry) Lookup(tag string) (Language, bool) {
+	for _, l := range r.languages {
+		if strings.EqualFold(l.Tag, tag) {
+			return l, true
+		}
+	}
+	return Language{}, false
+}
+
+// T returns the message of the key translated to the language. If there is no
+// translation, the message of the fallback language is used, and if it also does
+// not exist, the key itself is returned. Arguments are formatted into the message
+// using [fmt.Sprintf].
+func (r *Registry) T(lang, key string, args ...any) string {
+	msg, ok := r.catalogs[strings.ToLower(lang)][key]
+	if !ok {
+		msg, ok = r.catalogs[strings.ToLower(r.fallback)][key]
+	}
+	if !ok {
+		return key
+	}
+
+	if len(args) > 0 {
+		return fmt.Sprintf(msg, args...)
+	}
+	return msg
+}
+
+// HTML is the same as [Registry.T], but marks the message as trusted HTML. It
+// should only be used for messages of catalogs controlled by the application.
+func (r *Registry) HTML(lang, key string, args ...any) template.HTML {
+	return template.HTML(r.T(lang, key, args...))
+}
@@ -0,0 +1,129 @@
+package i18n
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Preference is a language range of an Accept-Language header and its quality value.
+type Preference struct {
+	Range string
+	Q     float64
+}
+
+// ParseAcceptLanguage parses the value of an Accept-Language header (RFC 9110,
+// Section 12.5.4) into its language ranges, sorted by descending quality. Ranges
+// with the same quality keep the order of the header, and ranges with quality
+// zero or invalid syntax are dropped.
+func ParseAcceptLanguage(header string) []Preference {
+	var prefs []Preference
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		tag = strings.TrimSpace(tag)
+		if !validRange(tag) {
+			continue
+		}
+
+		q := 1.0
+		for _, p := range strings.Split(params, ";") {
+			k, v, ok := strings.Cut(strings.TrimSpace(p), "=")
+			if !ok || !strings.EqualFold(strings.TrimSpace(k), "q") {
+				continue
+			}
+			f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+			if err != nil || f < 0 || f > 1 {
+				q = 0
+			} else {
+				q = f
+			}
+		}
+		if q == 0 {
+			continue
+		}
+
+		prefs = append(prefs, Preference{Range: tag, Q: q})
+	}
+
+	sort.SliceStable(prefs, func(i, j int) bool {
+		return prefs[i].Q > prefs[j].Q
+	})
+
+	return prefs
+}
+
+// Match returns the supported language that best matches the ranges, in order of
+// preference. Each range is first compared to the supported tags as a whole,
+// then by truncating its subtags (RFC 4647, Section 3.4) and lastly by its
+// primary language subtag, so "pt-PT" matches "pt-BR" if "pt-PT" and "pt" are
+// not supported. The wildcard "*" matches the fallback language.
+func (r *Registry) Match(ranges ...string) (Language, bool) {
+	for _, rg := range ranges {
+		if rg == "*" {
+			return r.Fallback(), true
+		}
+
+		for t := rg; t != ""; t = truncate(t) {
+			if l, ok := r.Lookup(t); ok {
+				return l, true
+			}
+		}
+
+		primary, _, _ := strings.Cut(rg, "-")
+		for _, l := range r.languages {
+			if p, _, _ := strings.Cut(l.Tag, "-"); strings.EqualFold(p, primary) {
+				return l, true
+			}
+		}
+	}
+	return Language{}, false
+}
+
+// Negotiate returns the supported language that best matches the Accept-Language
+// header, or the fallback language if none matches.
+func (r *Registry) Negotiate(acceptLanguage string) Language {
+	prefs := ParseAcceptLanguage(acceptLanguage)
+
+	ranges := make([]string, len(prefs))
+	for i, p := range prefs {
+		ranges[i] = p.Range
+	}
+
+	if l, ok := r.Match(ranges...); ok {
+		return l
+	}
+	return r.Fallback()
+}
+
+func truncate(tag string) string {
+	i := strings.LastIndex(tag, "-")
+	if i < 0 {
+		return ""
+	}
+	tag = tag[:i]
+	// Single-character subtags (e.g. "x" of private use) cannot end a range.
+	if i = strings.LastIndex(tag, "-"); i >= 0 && len(tag)-i == 2 {
+		tag = tag[:i]
+	}
+	return tag
+}
+
+func validRange(tag string) bool {
+	if tag == "*" {
+		return true
+	}
+	if tag == "" {
+		return false
+	}
+	for _, s := range strings.Split(tag, "-") {
+		if len(s) == 0 || len(s) > 8 {
+			return false
+		}
+		for _, c := range s {
+			if (c < 'a' || c > 'z') && (c < 'A' || c > 'Z') && (c < '0' || c > '9') {
+				return false
+			}
+		}
+	}
+	return true
+}
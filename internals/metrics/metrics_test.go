@@ -0,0 +1,127 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func expose(t *testing.T, r *Registry) string {
+	t.Helper()
+	var b strings.Builder
+	n, err := r.WriteTo(&b)
+	if err != nil {
+		t.Fatalf("WriteTo() error = %v", err)
+	}
+	if int(n) != b.Len() {
+		t.Errorf("WriteTo() = %d, wrote %d bytes", n, b.Len())
+	}
+	return b.String()
+}
+
+func TestCounter(t *testing.T) {
+	r := New()
+	c := r.Counter("requests_total", "Number of requests.", "route", "status")
+	c.Inc("/b", "200")
+	c.Inc("/a", "404")
+	c.Add(2.5, "/a", "404")
+	c.Inc("/a", "200")
+
+	want := `# HELP requests_total Number of requests.
+# TYPE requests_total counter
+requests_total{route="/a",status="200"} 1
+requests_total{route="/a",status="404"} 3.5
+requests_total{route="/b",status="200"} 1
+`
+	if got := expose(t, r); got != want {
+		t.Errorf("exposition:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestCounterLabels(t *testing.T) {
+	r := New()
+	c := r.Counter("total", "Help with a \\ backslash\nand a new line.", "path")
+	c.Inc(`quote " backslash \ new line` + "\n")
+
+	want := `# HELP total Help with a \\ backslash\nand a new line.
+# TYPE total counter
+total{path="quote \" backslash \\ new line\n"} 1
+`
+	if got := expose(t, r); got != want {
+		t.Errorf("exposition:\n%s\nwant:\n%s", got, want)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Inc() with missing label values did not panic")
+		}
+	}()
+	c.Inc()
+}
+
+func TestHistogram(t *testing.T) {
+	r := New()
+	h := r.Histogram("duration_seconds", "Duration.", []float64{0.1, 0.5, 1}, "route")
+	h.Observe(0.05, "/")
+	h.Observe(0.1, "/")
+	h.Observe(0.7, "/")
+	h.Observe(3, "/")
+	h.Observe(0.2, "/blog/")
+
+	want := `# HELP duration_seconds Duration.
+# TYPE duration_seconds histogram
+duration_seconds_bucket{route="/",le="0.1"} 2
+duration_seconds_bucket{route="/",le="0.5"} 2
+duration_seconds_bucket{route="/",le="1"} 3
+duration_seconds_bucket{route="/",le="+Inf"} 4
+duration_seconds_sum{route="/"} 3.85
+duration_seconds_count{route="/"} 4
+duration_seconds_bucket{route="/blog/",le="0.1"} 0
+duration_seconds_bucket{route="/blog/",le="0.5"} 1
+duration_seconds_bucket{route="/blog/",le="1"} 1
+duration_seconds_bucket{route="/blog/",le="+Inf"} 1
+duration_seconds_sum{route="/blog/"} 0.2
+duration_seconds_count{route="/blog/"} 1
+`
+	if got := expose(t, r); got != want {
+		t.Errorf("exposition:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestFuncMetricsAndOrder(t *testing.T) {
+	r := New()
+	entries := 3.0
+	r.GaugeFunc("entries", "Entries.", func() float64 { return entries })
+	r.CounterFunc("hits_total", "Hits.", func() float64 { return 7 })
+	r.Counter("empty_total", "Nothing counted yet.", "label")
+
+	entries = 4
+	want := `# HELP entries Entries.
+# TYPE entries gauge
+entries 4
+# HELP hits_total Hits.
+# TYPE hits_total counter
+hits_total 7
+# HELP empty_total Nothing counted yet.
+# TYPE empty_total counter
+`
+	if got := expose(t, r); got != want {
+		t.Errorf("exposition:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	r := New()
+	r.CounterFunc("hits_total", "Hits.", func() float64 { return 1 })
+
+	w := httptest.NewRecorder()
+	r.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	if ct := w.Header().Get("Content-Type"); ct != ContentType {
+		t.Errorf("Content-Type = %q, want %q", ct, ContentType)
+	}
+	if !strings.Contains(w.Body.String(), "hits_total 1\n") {
+		t.Errorf("body does not contain the metric:\n%s", w.Body.String())
+	}
+}
@@ -0,0 +1,68 @@
+package posts
+
+import (
+	"slices"
+	"sort"
+	"strings"
+
+	"capytal.cc/internals/natsort"
+)
+
+// Neighbours returns the posts before and after the post with the name in the
+// order of [Sort]: prev is the next older post and next is the next newer one.
+// Both are nil if the post is not in the list.
+func Neighbours(ps []*Post, name string) (prev, next *Post) {
+	sorted := slices.Clone(ps)
+	Sort(sorted)
+
+	i := slices.IndexFunc(sorted, func(p *Post) bool { return p.Name == name })
+	if i < 0 {
+		return nil, nil
+	}
+
+	if i+1 < len(sorted) {
+		prev = sorted[i+1]
+	}
+	if i > 0 {
+		next = sorted[i-1]
+	}
+	return prev, next
+}
+
+// Series is a group of posts sharing the same "series" front matter key.
+type Series struct {
+	Name string
+	// Part is the position of the current post in the series, starting at one.
+	Part int
+	// Posts are all parts of the series, from oldest to newest.
+	Posts []*Post
+}
+
+// SeriesOf returns the series of the post, built from the posts with the same
+// series name, compared case-insensitively. The post itself is always part of
+// the series, even if it is not in the list. Returns false if the post is not
+// in a series.
+func SeriesOf(ps []*Post, p *Post) (Series, bool) {
+	if p.Series == "" {
+		return Series{}, false
+	}
+
+	parts := filter(ps, func(o *Post) bool {
+		return o.Name != p.Name && strings.EqualFold(o.Series, p.Series)
+	})
+	parts = append(parts, p)
+	// Parts are read in order, so, unlike [Sort], parts with the same date are
+	// in the ascending natural order of their names, e.g. "part-2" before "part-10".
+	sort.SliceStable(parts, func(i, j int) bool {
+		if !parts[i].Date.Equal(parts[j].Date) {
+			return parts[i].Date.Before(parts[j].Date)
+		}
+		return natsort.Compare(parts[i].Name, parts[j].Name)
+	})
+
+	return Series{
+		Name:  p.Series,
+		Part:  slices.Index(parts, p) + 1,
+		Posts: parts,
+	}, true
+}
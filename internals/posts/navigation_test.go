@@ -0,0 +1,80 @@
+package posts
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSeriesOf(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2025, 1, d, 0, 0, 0, 0, time.UTC) }
+	part := func(name, series string, date time.Time) *Post {
+		return &Post{Name: name, Series: series, Date: date}
+	}
+
+	tests := []struct {
+		name  string
+		posts []*Post
+		want  string
+	}{
+		{
+			name: "dated",
+			posts: []*Post{
+				part("c.md", "go", day(3)),
+				part("a.md", "go", day(1)),
+				part("b.md", "Go", day(2)),
+				part("other.md", "rust", day(2)),
+			},
+			want: "a.md b.md c.md",
+		},
+		{
+			name: "same date",
+			posts: []*Post{
+				part("part-10.md", "go", day(1)),
+				part("part-2.md", "go", day(1)),
+				part("part-1.md", "go", day(1)),
+				part("intro.md", "go", time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC)),
+			},
+			want: "intro.md part-1.md part-2.md part-10.md",
+		},
+		{
+			name: "undated",
+			posts: []*Post{
+				part("part-10.md", "go", time.Time{}),
+				part("part-1.md", "go", time.Time{}),
+				part("part-2.md", "go", time.Time{}),
+			},
+			want: "part-1.md part-2.md part-10.md",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, p := range tt.posts {
+				if p.Series == "rust" {
+					continue
+				}
+
+				s, ok := SeriesOf(tt.posts, p)
+				if !ok {
+					t.Fatalf("SeriesOf(%s) is not a series", p.Name)
+				}
+
+				names := make([]string, len(s.Posts))
+				for i, o := range s.Posts {
+					names[i] = o.Name
+				}
+				if got := strings.Join(names, " "); got != tt.want {
+					t.Errorf("SeriesOf(%s) = %s, want %s", p.Name, got, tt.want)
+				}
+				if s.Posts[s.Part-1] != p {
+					t.Errorf("SeriesOf(%s) part = %d, want the position of the post", p.Name, s.Part)
+				}
+			}
+		})
+	}
+
+	if _, ok := SeriesOf(nil, &Post{Name: "alone.md"}); ok {
+		t.Error("SeriesOf() of a post without a series is a series")
+	}
+}
@@ -0,0 +1,74 @@
+package posts
+
+import (
+	"math"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/yuin/goldmark/ast"
+)
+
+// WordsPerMinute is the average reading speed used to estimate reading times.
+const WordsPerMinute = 220
+
+// Heading is an entry of a post's table of contents.
+type Heading struct {
+	Level int
+	// ID is the value of the heading's "id" attribute, set by the parser's
+	// automatic heading IDs, to be used as a fragment link.
+	ID       string
+	Title    string
+	Children []*Heading
+}
+
+// WordCount returns the number of words in the post's text.
+func (p *Post) WordCount() int {
+	return len(strings.FieldsFunc(p.Text(), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r) && r != '\'' && r != '-'
+	}))
+}
+
+// ReadingTime returns the estimated time to read the post, rounded up to the minute.
+func (p *Post) ReadingTime() time.Duration {
+	minutes := math.Ceil(float64(p.WordCount()) / WordsPerMinute)
+	return time.Duration(max(minutes, 1)) * time.Minute
+}
+
+// Outline returns the headings of the post as a nested table of contents. The
+// first level one heading is considered the title of the post and is not included.
+func (p *Post) Outline() []*Heading {
+	root := &Heading{}
+	stack := []*Heading{root}
+	skippedTitle := false
+
+	_ = ast.Walk(p.doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		h, ok := n.(*ast.Heading)
+		if !entering || !ok {
+			return ast.WalkContinue, nil
+		}
+
+		if h.Level == 1 && !skippedTitle {
+			skippedTitle = true
+			return ast.WalkSkipChildren, nil
+		}
+
+		heading := &Heading{Level: h.Level, Title: plainText(h, p.Source)}
+		if id, ok := h.AttributeString("id"); ok {
+			if id, ok := id.([]byte); ok {
+				heading.ID = string(id)
+			}
+		}
+
+		for len(stack) > 1 && stack[len(stack)-1].Level >= h.Level {
+			stack = stack[:len(stack)-1]
+		}
+		parent := stack[len(stack)-1]
+		parent.Children = append(parent.Children, heading)
+		stack = append(stack, heading)
+
+		return ast.WalkSkipChildren, nil
+	})
+
+	return root.Children
+}
@@ -0,0 +1,97 @@
+package posts
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/yuin/goldmark"
+	meta "github.com/yuin/goldmark-meta"
+	"github.com/yuin/goldmark/parser"
+	"go.abhg.dev/goldmark/anchor"
+)
+
+func outline(hs []*Heading) string {
+	l := make([]string, len(hs))
+	for i, h := range hs {
+		l[i] = fmt.Sprintf("%d:%s#%s", h.Level, h.Title, h.ID)
+		if len(h.Children) > 0 {
+			l[i] += "(" + outline(h.Children) + ")"
+		}
+	}
+	return strings.Join(l, " ")
+}
+
+func TestOutline(t *testing.T) {
+	// The parser is configured as the blog's renderer, whose anchor extension
+	// adds links to the headings which are not part of their titles.
+	md := goldmark.New(
+		goldmark.WithParserOptions(parser.WithAutoHeadingID()),
+		goldmark.WithExtensions(meta.New(meta.WithStoresInDocument()), &anchor.Extender{}),
+	)
+
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{
+			name: "nested",
+			src:  "# Title\n\n## One\n\n### One.One\n\n### One.Two\n\n## Two\n",
+			want: "2:One#one(3:One.One#oneone 3:One.Two#onetwo) 2:Two#two",
+		},
+		{
+			name: "skipped levels",
+			src:  "# Title\n\n## One\n\n#### Deep\n\n### Less deep\n\n## Two\n\n###### Deepest\n",
+			want: "2:One#one(4:Deep#deep 3:Less deep#less-deep) 2:Two#two(6:Deepest#deepest)",
+		},
+		{
+			name: "starting deeper",
+			src:  "# Title\n\n### Three\n\n## Two\n\n### Three\n",
+			want: "3:Three#three 2:Two#two(3:Three#three-1)",
+		},
+		{
+			name: "duplicate ids",
+			src:  "# Title\n\n## Notes\n\n### Notes\n\n## Notes\n",
+			want: "2:Notes#notes(3:Notes#notes-1) 2:Notes#notes-2",
+		},
+		{
+			name: "other level one headings",
+			src:  "# Title\n\n## One\n\n# Appendix\n\n## Two\n",
+			want: "2:One#one 1:Appendix#appendix(2:Two#two)",
+		},
+		{
+			name: "markup in titles",
+			src:  "# Title\n\n## The `code` and *emphasis*\n",
+			want: "2:The code and emphasis#the-code-and-emphasis",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := Parse(md, "post.md", []byte(tt.src))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := outline(p.Outline()); got != tt.want {
+				t.Errorf("Outline() = %s, want %s", got, tt.want)
+			}
+
+			// The IDs link to the anchors of the rendered headings.
+			html, err := p.Render(md)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var check func(hs []*Heading)
+			check = func(hs []*Heading) {
+				for _, h := range hs {
+					if !strings.Contains(html, `id="`+h.ID+`"`) || !strings.Contains(html, `href="#`+h.ID+`"`) {
+						t.Errorf("rendered post has no anchor of %q:\n%s", h.ID, html)
+					}
+					check(h.Children)
+				}
+			}
+			check(p.Outline())
+		})
+	}
+}
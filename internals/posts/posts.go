@@ -0,0 +1,439 @@
+// Package posts loads blog posts from a file system and reads their front matter.
+package posts
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"capytal.cc/internals/natsort"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/text"
+)
+
+// Post is a single blog post source and the metadata read from its front matter.
+type Post struct {
+	// Name of the file in the source file system, used as the post's path.
+	Name string
+
+	Title       string
+	Description string
+	Date        time.Time
+	Modified    time.Time
+	Tags        []string
+	Category    string
+	// Series is the name of the series of posts this post is part of.
+	Series string
+	// TranslationKey identifies the translations of the same post in other
+	// languages, for posts which do not share the same name.
+	TranslationKey string
+
+	// Draft posts are not published, independently of their publish date.
+	Draft bool
+	// Publish is the time the post becomes publicly available. Defaults to the
+	// post's date.
+	Publish time.Time
+
+	// Meta is the raw front matter of the post.
+	Meta map[string]any
+
+	// Source is the raw markdown of the post.
+	Source []byte
+
+	doc ast.Node
+}
+
+// Parse reads the front matter and title of a post. The markdown parser must
+// have the goldmark-meta extension enabled with documents storing the metadata.
+// Dates missing from the front matter are left zero; [Load] sets their defaults.
+func Parse(md goldmark.Markdown, name string, src []byte) (*Post, error) {
+	doc := md.Parser().Parse(text.NewReader(src))
+	meta := doc.OwnerDocument().Meta()
+
+	p := &Post{
+		Name:   name,
+		Meta:   meta,
+		Source: src,
+		doc:    doc,
+	}
+
+	if t, ok := meta["title"].(string); ok {
+		p.Title = t
+	} else {
+		p.Title = headingTitle(doc, src)
+	}
+	if p.Title == "" {
+		p.Title = strings.TrimSuffix(name, path.Ext(name))
+	}
+
+	if d, ok := meta["description"].(string); ok {
+		p.Description = d
+	} else if d, ok := meta["summary"].(string); ok {
+		p.Description = d
+	} else {
+		p.Description = firstParagraph(doc, src)
+	}
+
+	p.Tags = Strings(meta, "tags")
+	if c, ok := meta["category"].(string); ok {
+		p.Category = strings.TrimSpace(c)
+	}
+	if s, ok := meta["series"].(string); ok {
+		p.Series = strings.TrimSpace(s)
+	}
+	if k, ok := meta["translationKey"].(string); ok {
+		p.TranslationKey = strings.TrimSpace(k)
+	}
+
+	var err error
+	if p.Date, err = Time(meta, "date"); err != nil {
+		return nil, fmt.Errorf("invalid date of post %q: %w", name, err)
+	}
+	if p.Modified, err = Time(meta, "modified"); err != nil {
+		return nil, fmt.Errorf("invalid modified date of post %q: %w", name, err)
+	}
+
+	if d, ok := meta["draft"].(bool); ok {
+		p.Draft = d
+	}
+	if p.Publish, err = Time(meta, "publish"); err != nil {
+		return nil, fmt.Errorf("invalid publish date of post %q: %w", name, err)
+	}
+
+	return p, nil
+}
+
+// defaultDates sets the dates missing from the front matter of the post: it is
+// dated by modTime, if it is not zero, and its modification date defaults to its
+// date.
+func (p *Post) defaultDates(modTime time.Time) {
+	if p.Date.IsZero() {
+		p.Date = modTime
+	}
+	if p.Modified.IsZero() {
+		p.Modified = p.Date
+	}
+}
+
+// IsPublished reports if the post is not a draft and its publish date, or date
+// if it has none, is not after now.
+func (p *Post) IsPublished(now time.Time) bool {
+	if p.Draft {
+		return false
+	}
+
+	publish := p.Publish
+	if publish.IsZero() {
+		publish = p.Date
+	}
+	return !publish.After(now)
+}
+
+// Published filters the posts that are published at the time. It also returns
+// the earliest time a not yet published post is scheduled to be, or the zero
+// time if there is none.
+func Published(ps []*Post, now time.Time) ([]*Post, time.Time) {
+	var next time.Time
+	published := filter(ps, func(p *Post) bool {
+		if p.IsPublished(now) {
+			return true
+		}
+
+		publish := p.Publish
+		if publish.IsZero() {
+			publish = p.Date
+		}
+		if !p.Draft && (next.IsZero() || publish.Before(next)) {
+			next = publish
+		}
+		return false
+	})
+	return published, next
+}
+
+// Summary is the subset of a post's metadata used to list it in indexes.
+type Summary struct {
+	Name        string
+	Title       string
+	Description string
+	Date        time.Time
+	Tags        []string
+	Category    string
+}
+
+// Summary returns the metadata of the post to be used in indexes.
+func (p *Post) Summary() Summary {
+	return Summary{
+		Name:        p.Name,
+		Title:       p.Title,
+		Description: p.Description,
+		Date:        p.Date,
+		Tags:        p.Tags,
+		Category:    p.Category,
+	}
+}
+
+// Key returns the key shared by the post and its translations, its translation
+// key or, if it has none, its name.
+func (p *Post) Key() string {
+	if p.TranslationKey != "" {
+		return p.TranslationKey
+	}
+	return p.Name
+}
+
+// Document returns the parsed markdown AST of the post.
+func (p *Post) Document() ast.Node {
+	return p.doc
+}
+
+// Text returns the plain text content of the post, without markup or front matter.
+func (p *Post) Text() string {
+	b := new(strings.Builder)
+	_ = ast.Walk(p.doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			if n.Type() == ast.TypeBlock {
+				b.WriteByte('\n')
+			}
+			return ast.WalkContinue, nil
+		}
+
+		switch n := n.(type) {
+		case *ast.Text:
+			b.Write(n.Segment.Value(p.Source))
+			if n.SoftLineBreak() || n.HardLineBreak() {
+				b.WriteByte(' ')
+			}
+		case *ast.String:
+			b.Write(n.Value)
+		case *ast.FencedCodeBlock, *ast.CodeBlock:
+			lines := n.Lines()
+			for i := 0; i < lines.Len(); i++ {
+				l := lines.At(i)
+				b.Write(l.Value(p.Source))
+			}
+		}
+		return ast.WalkContinue, nil
+	})
+	return strings.TrimSpace(b.String())
+}
+
+// Render renders the post's markdown into HTML.
+func (p *Post) Render(md goldmark.Markdown) (string, error) {
+	b := new(strings.Builder)
+	if err := md.Renderer().Render(b, p.Source, p.doc); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// Load parses all markdown posts in the root directory of fsys, sorted from
+// newest to oldest and, on equal dates, by the natural order of their names.
+// Posts that can't be parsed are logged and skipped, so a single invalid post
+// doesn't make the whole blog unavailable.
+func Load(md goldmark.Markdown, fsys fs.FS, log *slog.Logger, opts ...LoadOption) ([]*Post, error) {
+	o := loadOptions{modTime: true}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+
+	ps := make([]*Post, 0, len(entries))
+	for _, e := range entries {
+		if e.IsDir() || Ignored(e.Name()) || !IsMarkdown(e.Name()) {
+			continue
+		}
+
+		src, err := fs.ReadFile(fsys, e.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		p, err := Parse(md, e.Name(), src)
+		if err != nil {
+			log.Warn("Skipping invalid post",
+				slog.String("post", e.Name()),
+				slog.String("error", err.Error()),
+			)
+			continue
+		}
+
+		var modTime time.Time
+		if info, err := e.Info(); err == nil && o.modTime {
+			modTime = info.ModTime()
+		}
+		p.defaultDates(modTime)
+
+		ps = append(ps, p)
+	}
+
+	Sort(ps)
+
+	return ps, nil
+}
+
+// LoadOption configures how [Load] reads the posts.
+type LoadOption func(o *loadOptions)
+
+type loadOptions struct {
+	modTime bool
+}
+
+// WithoutModTime leaves posts without a date in their front matter undated.
+// By default they are dated by the modification time of their files, which
+// depends on when the copy of the source was made, not on its content.
+func WithoutModTime() LoadOption {
+	return func(o *loadOptions) { o.modTime = false }
+}
+
+// Fingerprint returns a hash of the names and sources of the posts, which changes
+// if any post is added, removed or modified.
+func Fingerprint(ps []*Post) string {
+	h := sha256.New()
+	for _, p := range ps {
+		h.Write([]byte(p.Name))
+		h.Write([]byte{0})
+		h.Write(p.Source)
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Sort sorts posts from newest to oldest, using the natural order of their
+// names as a tie-breaker.
+func Sort(ps []*Post) {
+	sort.SliceStable(ps, func(i, j int) bool {
+		if !ps[i].Date.Equal(ps[j].Date) {
+			return ps[i].Date.After(ps[j].Date)
+		}
+		return natsort.Compare(ps[i].Name, ps[j].Name)
+	})
+}
+
+// Ignored reports if the file name is not a blog post, such as hidden files and
+// repository metadata.
+func Ignored(name string) bool {
+	name = path.Base(name)
+	return strings.HasPrefix(name, ".") || slices.Contains([]string{
+		"LICENSE",
+		"LICENSE.md",
+		"README.md",
+		"CONTRIBUTING.md",
+		"CHANGELOG.md",
+		"CODE_OF_CONDUCT.md",
+		"SECURITY.md",
+	}, name)
+}
+
+// IsMarkdown reports if the file name has the extension of markdown posts.
+func IsMarkdown(name string) bool {
+	return strings.EqualFold(path.Ext(name), ".md")
+}
+
+var timeLayouts = []string{
+	time.RFC3339,
+	time.DateTime,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04",
+	time.DateOnly,
+}
+
+// Time reads the key of the front matter as a timestamp. A missing key returns
+// the zero time and no error.
+func Time(meta map[string]any, key string) (time.Time, error) {
+	v, ok := meta[key]
+	if !ok || v == nil {
+		return time.Time{}, nil
+	}
+
+	switch v := v.(type) {
+	case time.Time:
+		return v, nil
+	case string:
+		for _, l := range timeLayouts {
+			if t, err := time.Parse(l, v); err == nil {
+				return t, nil
+			}
+		}
+		return time.Time{}, fmt.Errorf("unsupported time format %q", v)
+	default:
+		return time.Time{}, fmt.Errorf("cannot use type %T as time", v)
+	}
+}
+
+// Strings reads the key of the front matter as a list of strings. Both YAML lists
+// and comma-separated strings are accepted.
+func Strings(meta map[string]any, key string) []string {
+	var l []string
+	switch v := meta[key].(type) {
+	case []any:
+		for _, i := range v {
+			if s := strings.TrimSpace(fmt.Sprint(i)); s != "" {
+				l = append(l, s)
+			}
+		}
+	case string:
+		for _, s := range strings.Split(v, ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				l = append(l, s)
+			}
+		}
+	}
+	return l
+}
+
+func headingTitle(doc ast.Node, src []byte) string {
+	title := ""
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if h, ok := n.(*ast.Heading); !ok || h.Level > 1 {
+			return ast.WalkContinue, nil
+		}
+
+		title = plainText(n, src)
+		return ast.WalkStop, nil
+	})
+	return title
+}
+
+func firstParagraph(doc ast.Node, src []byte) string {
+	for n := doc.FirstChild(); n != nil; n = n.NextSibling() {
+		if n.Kind() == ast.KindParagraph {
+			return plainText(n, src)
+		}
+	}
+	return ""
+}
+
+func plainText(n ast.Node, src []byte) string {
+	b := new(strings.Builder)
+	_ = ast.Walk(n, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch n := n.(type) {
+		case *ast.Text:
+			b.Write(n.Segment.Value(src))
+			if n.SoftLineBreak() || n.HardLineBreak() {
+				b.WriteByte(' ')
+			}
+		case *ast.String:
+			b.Write(n.Value)
+		}
+		return ast.WalkContinue, nil
+	})
+	return strings.TrimSpace(b.String())
+}
@@ -0,0 +1,100 @@
+package posts
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/yuin/goldmark"
+	meta "github.com/yuin/goldmark-meta"
+)
+
+func testMarkdown() goldmark.Markdown {
+	return goldmark.New(goldmark.WithExtensions(meta.New(meta.WithStoresInDocument())))
+}
+
+func TestLoad(t *testing.T) {
+	post := func(front string) *fstest.MapFile {
+		return &fstest.MapFile{Data: []byte("---\n" + front + "\n---\n\n# Title\n\nContent.\n")}
+	}
+
+	fsys := fstest.MapFS{
+		"first.md":        post("date: 2025-01-01"),
+		"second.MD":       post("date: 2025-02-01"),
+		"bad-date.md":     post("date: yesterday"),
+		"bad-modified.md": post("date: 2025-01-01\nmodified: [2025]"),
+		"bad-publish.md":  post("date: 2025-01-01\npublish: tomorrow"),
+
+		"image.png":        {Data: []byte("\x89PNG")},
+		"notes.yaml":       {Data: []byte("title: notes\n")},
+		"notes.txt":        {Data: []byte("notes\n")},
+		"README.md":        post("date: 2025-01-01"),
+		"CONTRIBUTING.md":  post("date: 2025-01-01"),
+		"LICENSE":          {Data: []byte("license\n")},
+		".draft.md":        post("date: 2025-01-01"),
+		"assets/nested.md": post("date: 2025-01-01"),
+	}
+
+	var logs bytes.Buffer
+	ps, err := Load(testMarkdown(), fsys, slog.New(slog.NewTextHandler(&logs, nil)))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	var names []string
+	for _, p := range ps {
+		names = append(names, p.Name)
+	}
+	if got, want := strings.Join(names, " "), "second.MD first.md"; got != want {
+		t.Errorf("Load() = %s, want %s", got, want)
+	}
+
+	for _, name := range []string{"bad-date.md", "bad-modified.md", "bad-publish.md"} {
+		if !strings.Contains(logs.String(), "post="+name) {
+			t.Errorf("invalid post %s was not logged:\n%s", name, logs.String())
+		}
+	}
+}
+
+func TestLoadDates(t *testing.T) {
+	modTime := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	fsys := fstest.MapFS{
+		"dated.md":    {Data: []byte("---\ndate: 2025-01-01\n---\n# Dated\n")},
+		"modified.md": {Data: []byte("---\ndate: 2025-01-01\nmodified: 2025-02-01\n---\n# Modified\n")},
+		"undated.md":  {Data: []byte("# Undated\n"), ModTime: modTime},
+	}
+
+	ps, err := Load(testMarkdown(), fsys, slog.New(slog.DiscardHandler))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string][2]string{
+		"dated.md":    {"2025-01-01", "2025-01-01"},
+		"modified.md": {"2025-01-01", "2025-02-01"},
+		"undated.md":  {"2025-03-01", "2025-03-01"},
+	}
+	for _, p := range ps {
+		got := [2]string{p.Date.Format(time.DateOnly), p.Modified.Format(time.DateOnly)}
+		if got != want[p.Name] {
+			t.Errorf("%s: date and modified = %v, want %v", p.Name, got, want[p.Name])
+		}
+	}
+}
+
+func TestLoadWithoutModTime(t *testing.T) {
+	fsys := fstest.MapFS{
+		"undated.md": {Data: []byte("# Undated\n"), ModTime: time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)},
+	}
+
+	ps, err := Load(testMarkdown(), fsys, slog.New(slog.DiscardHandler), WithoutModTime())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ps) != 1 || !ps[0].Date.IsZero() || !ps[0].Modified.IsZero() {
+		t.Errorf("Load() = %+v, want an undated post", ps)
+	}
+}
@@ -0,0 +1,82 @@
+package posts
+
+import (
+	"sort"
+	"strings"
+
+	"capytal.cc/internals/natsort"
+)
+
+// Term is a tag or category name and the number of posts classified by it.
+type Term struct {
+	Name  string
+	Count int
+}
+
+// Tags returns all tags used by the posts, sorted by their natural order.
+func Tags(ps []*Post) []Term {
+	return terms(ps, func(p *Post) []string { return p.Tags })
+}
+
+// Categories returns all categories used by the posts, sorted by their natural order.
+func Categories(ps []*Post) []Term {
+	return terms(ps, func(p *Post) []string {
+		if p.Category == "" {
+			return nil
+		}
+		return []string{p.Category}
+	})
+}
+
+// WithTag filters the posts that have the tag, compared case-insensitively.
+func WithTag(ps []*Post, tag string) []*Post {
+	return filter(ps, func(p *Post) bool {
+		for _, t := range p.Tags {
+			if strings.EqualFold(t, tag) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// InCategory filters the posts that are in the category, compared case-insensitively.
+func InCategory(ps []*Post, category string) []*Post {
+	return filter(ps, func(p *Post) bool {
+		return p.Category != "" && strings.EqualFold(p.Category, category)
+	})
+}
+
+func terms(ps []*Post, get func(*Post) []string) []Term {
+	counts := map[string]*Term{}
+	for _, p := range ps {
+		for _, n := range get(p) {
+			k := strings.ToLower(n)
+			if t, ok := counts[k]; ok {
+				t.Count++
+			} else {
+				counts[k] = &Term{Name: n, Count: 1}
+			}
+		}
+	}
+
+	l := make([]Term, 0, len(counts))
+	for _, t := range counts {
+		l = append(l, *t)
+	}
+	sort.Slice(l, func(i, j int) bool {
+		return natsort.Compare(strings.ToLower(l[i].Name), strings.ToLower(l[j].Name))
+	})
+
+	return l
+}
+
+func filter(ps []*Post, keep func(*Post) bool) []*Post {
+	l := make([]*Post, 0, len(ps))
+	for _, p := range ps {
+		if keep(p) {
+			l = append(l, p)
+		}
+	}
+	return l
+}
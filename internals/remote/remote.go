@@ -0,0 +1,224 @@
+// Package remote fetches documents over HTTP, caching them in memory so remote
+// servers being slow or unavailable do not affect the pages that use them.
+package remote
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"capytal.cc/internals/trace"
+)
+
+// MaxSize is the maximum size in bytes of a fetched document.
+const MaxSize = 4 << 20
+
+// Document is the body of a fetched URL.
+type Document struct {
+	Body         []byte
+	ETag         string
+	LastModified string
+	// Fetched is the last time the document was fetched or revalidated.
+	Fetched time.Time
+	// Stale reports if the document could not be revalidated and is the last
+	// successfully fetched version.
+	Stale bool
+}
+
+// StatusError is returned when the server responds with an unexpected status.
+type StatusError struct {
+	URL  string
+	Code int
+}
+
+func (err *StatusError) Error() string {
+	return fmt.Sprintf("unexpected status %d %s fetching %q", err.Code, http.StatusText(err.Code), err.URL)
+}
+
+// NotFound reports if the server responded that the document doesn't exist or
+// was removed.
+func (err *StatusError) NotFound() bool {
+	return err.Code == http.StatusNotFound || err.Code == http.StatusGone
+}
+
+// Fetcher fetches and caches remote documents. Documents are reused for the
+// fetcher's TTL and then revalidated using their ETag and Last-Modified headers.
+// If revalidating fails, the cached document is used for another TTL, unless the
+// server responds that it doesn't exist anymore.
+type Fetcher struct {
+	client  *http.Client
+	ttl     time.Duration
+	timeout time.Duration
+	log     *slog.Logger
+
+	mu    sync.Mutex
+	cache map[string]*entry
+}
+
+type entry struct {
+	mu      sync.Mutex
+	doc     *Document
+	checked time.Time
+}
+
+type Option func(f *Fetcher)
+
+// WithClient sets the HTTP client used to fetch documents.
+func WithClient(c *http.Client) Option {
+	return func(f *Fetcher) { f.client = c }
+}
+
+// WithTTL sets how long fetched documents are reused before being revalidated.
+// Defaults to 10 minutes.
+func WithTTL(d time.Duration) Option {
+	return func(f *Fetcher) { f.ttl = d }
+}
+
+// WithTimeout sets the maximum duration of a single fetch. Defaults to 5 seconds.
+func WithTimeout(d time.Duration) Option {
+	return func(f *Fetcher) { f.timeout = d }
+}
+
+// WithLogger sets the logger used to report documents served stale, for
+// contexts without a request-scoped logger.
+func WithLogger(l *slog.Logger) Option {
+	return func(f *Fetcher) { f.log = l }
+}
+
+// New creates a fetcher with an empty cache.
+func New(opts ...Option) *Fetcher {
+	f := &Fetcher{
+		client:  http.DefaultClient,
+		ttl:     10 * time.Minute,
+		timeout: 5 * time.Second,
+		log:     slog.New(slog.DiscardHandler),
+		cache:   map[string]*entry{},
+	}
+	for _, opt := range opts {
+		opt(f)
+	}
+	return f
+}
+
+// Get returns the document at the URL, from the cache if it was fetched in the
+// last TTL. The fetch is bounded by the context and the fetcher's timeout. If it
+// fails and a previous version of the document is cached, that version is
+// returned marked as stale instead of the error. If the server responds with
+// 404 Not Found or 410 Gone, the document is removed from the cache instead.
+func (f *Fetcher) Get(ctx context.Context, url string) (Document, error) {
+	ctx, span := trace.Start(ctx, "remote.Get")
+	defer span.Finish()
+	span.SetAttr("url", url)
+
+	e := f.entry(url)
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	now := time.Now()
+	if e.doc != nil && now.Sub(e.checked) < f.ttl {
+		span.SetAttr("cache", "hit")
+		return *e.doc, nil
+	}
+
+	doc, err := f.fetch(ctx, url, e.doc)
+	if err != nil {
+		span.SetError(err)
+
+		var status *StatusError
+		if errors.As(err, &status) && status.NotFound() {
+			// Fetches waiting for the entry must not serve it either.
+			e.doc = nil
+			f.Invalidate(url)
+		}
+		if e.doc == nil {
+			return Document{}, err
+		}
+
+		span.SetAttr("cache", "stale")
+		trace.Logger(ctx, f.log).Warn("Serving stale remote document",
+			slog.String("url", url),
+			slog.String("error", err.Error()),
+		)
+
+		e.doc.Stale = true
+		e.checked = now
+		return *e.doc, nil
+	}
+
+	e.doc, e.checked = doc, now
+	return *doc, nil
+}
+
+// Invalidate removes the document at the URL from the cache.
+func (f *Fetcher) Invalidate(url string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	delete(f.cache, url)
+}
+
+func (f *Fetcher) entry(url string) *entry {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	e, ok := f.cache[url]
+	if !ok {
+		e = &entry{}
+		f.cache[url] = e
+	}
+	return e
+}
+
+func (f *Fetcher) fetch(ctx context.Context, url string, cached *Document) (*Document, error) {
+	ctx, cancel := context.WithTimeout(ctx, f.timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if cached != nil {
+		if cached.ETag != "" {
+			req.Header.Set("If-None-Match", cached.ETag)
+		}
+		if cached.LastModified != "" {
+			req.Header.Set("If-Modified-Since", cached.LastModified)
+		}
+	}
+
+	res, err := f.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	switch {
+	case res.StatusCode == http.StatusNotModified && cached != nil:
+		doc := *cached
+		doc.Fetched, doc.Stale = time.Now(), false
+		return &doc, nil
+	case res.StatusCode != http.StatusOK:
+		return nil, &StatusError{URL: url, Code: res.StatusCode}
+	}
+
+	body, err := io.ReadAll(io.LimitReader(res.Body, MaxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(body) > MaxSize {
+		return nil, fmt.Errorf("document %q is larger than %d bytes", url, MaxSize)
+	}
+
+	return &Document{
+		Body:         body,
+		ETag:         res.Header.Get("ETag"),
+		LastModified: res.Header.Get("Last-Modified"),
+		Fetched:      time.Now(),
+	}, nil
+}
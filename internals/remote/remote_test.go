@@ -0,0 +1,65 @@
+package remote
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGetRemovedDocument(t *testing.T) {
+	tests := []struct {
+		name  string
+		code  int
+		stale bool
+	}{
+		{"not found", http.StatusNotFound, false},
+		{"gone", http.StatusGone, false},
+		{"server error", http.StatusInternalServerError, true},
+		{"bad gateway", http.StatusBadGateway, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code := http.StatusOK
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(code)
+				_, _ = w.Write([]byte("document"))
+			}))
+			defer srv.Close()
+
+			// A negative TTL revalidates the document on every Get.
+			f := New(WithClient(srv.Client()), WithTTL(-1))
+			ctx := context.Background()
+
+			if _, err := f.Get(ctx, srv.URL); err != nil {
+				t.Fatalf("Get() error = %v", err)
+			}
+
+			code = tt.code
+			for range 2 {
+				doc, err := f.Get(ctx, srv.URL)
+				if tt.stale {
+					if err != nil || !doc.Stale || string(doc.Body) != "document" {
+						t.Errorf("Get() = %+v, %v, want the stale document", doc, err)
+					}
+					continue
+				}
+
+				var status *StatusError
+				if !errors.As(err, &status) || status.Code != tt.code || !status.NotFound() {
+					t.Errorf("Get() error = %v, want a %d status error", err, tt.code)
+				}
+				if doc.Body != nil {
+					t.Errorf("Get() = %q, want no document", doc.Body)
+				}
+			}
+
+			code = http.StatusOK
+			if doc, err := f.Get(ctx, srv.URL); err != nil || doc.Stale {
+				t.Errorf("Get() = %+v, %v after the document is restored", doc, err)
+			}
+		})
+	}
+}
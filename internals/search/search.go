@@ -0,0 +1,205 @@
+// Package search implements a small in-memory full-text index with per-language
+// tokenization, accent folding and stemming for English and Portuguese.
+package search
+
+import (
+	"html"
+	"html/template"
+	"math"
+	"sort"
+	"strings"
+)
+
+// Document is a single searchable item of the index.
+type Document struct {
+	ID    string
+	Title string
+	Text  string
+}
+
+// Result is a document matching a query.
+type Result struct {
+	Document
+	Score float64
+	// Snippet is an excerpt of the document's text with the matched terms
+	// highlighted with <mark> elements.
+	Snippet template.HTML
+}
+
+// Index is an inverted index of documents, immutable after being created.
+type Index struct {
+	lang     string
+	docs     []Document
+	postings map[string]map[int]float64
+}
+
+// titleWeight is how much more a term in the document's title is worth than one
+// in its body.
+const titleWeight = 3
+
+// New creates an index of the documents, tokenized using the rules of the language.
+func New(lang string, docs []Document) *Index {
+	idx := &Index{
+		lang:     lang,
+		docs:     docs,
+		postings: map[string]map[int]float64{},
+	}
+
+	for i, d := range docs {
+		for _, t := range Terms(lang, d.Title) {
+			idx.add(t, i, titleWeight)
+		}
+		for _, t := range Terms(lang, d.Text) {
+			idx.add(t, i, 1)
+		}
+	}
+
+	return idx
+}
+
+func (idx *Index) add(term string, doc int, weight float64) {
+	p, ok := idx.postings[term]
+	if !ok {
+		p = map[int]float64{}
+		idx.postings[term] = p
+	}
+	p[doc] += weight
+}
+
+// Len returns the number of indexed documents.
+func (idx *Index) Len() int {
+	return len(idx.docs)
+}
+
+// Search returns the documents that have all terms of the query, sorted by
+// their TF-IDF score. A limit less or equal to zero returns all results.
+func (idx *Index) Search(query string, limit int) []Result {
+	terms := unique(Terms(idx.lang, query))
+	if len(terms) == 0 {
+		return nil
+	}
+
+	scores := map[int]float64{}
+	for i, t := range terms {
+		p := idx.postings[t]
+		if len(p) == 0 && i == len(terms)-1 {
+			// The last term may be still being typed, so it is matched as a prefix.
+			p = idx.prefix(t)
+		}
+		if len(p) == 0 {
+			return nil
+		}
+
+		idf := math.Log(1 + float64(len(idx.docs))/float64(len(p)))
+		next := map[int]float64{}
+		for doc, tf := range p {
+			if s, ok := scores[doc]; ok || i == 0 {
+				next[doc] = s + (1+math.Log(tf))*idf
+			}
+		}
+		scores = next
+	}
+
+	results := make([]Result, 0, len(scores))
+	for doc, score := range scores {
+		d := idx.docs[doc]
+		results = append(results, Result{
+			Document: d,
+			Score:    score,
+			Snippet:  snippet(idx.lang, d.Text, terms),
+		})
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].ID < results[j].ID
+	})
+
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+
+	return results
+}
+
+func (idx *Index) prefix(term string) map[int]float64 {
+	m := map[int]float64{}
+	for t, p := range idx.postings {
+		if !strings.HasPrefix(t, term) {
+			continue
+		}
+		for doc, tf := range p {
+			m[doc] += tf
+		}
+	}
+	return m
+}
+
+// snippetWords is the number of words shown around the first match of a snippet.
+const snippetWords = 30
+
+func snippet(lang, text string, terms []string) template.HTML {
+	tokens := Tokenize(lang, text)
+	if len(tokens) == 0 {
+		return ""
+	}
+
+	matches := func(t Token) bool {
+		for _, q := range terms {
+			if t.Term == q || strings.HasPrefix(t.Term, q) {
+				return true
+			}
+		}
+		return false
+	}
+
+	first := 0
+	for i, t := range tokens {
+		if matches(t) {
+			first = i
+			break
+		}
+	}
+
+	from := max(first-snippetWords/3, 0)
+	to := min(from+snippetWords, len(tokens))
+
+	b := new(strings.Builder)
+	if from > 0 {
+		b.WriteString("… ")
+	}
+
+	pos := tokens[from].Start
+	for _, t := range tokens[from:to] {
+		b.WriteString(html.EscapeString(text[pos:t.Start]))
+		if matches(t) {
+			b.WriteString("<mark>")
+			b.WriteString(html.EscapeString(text[t.Start:t.End]))
+			b.WriteString("</mark>")
+		} else {
+			b.WriteString(html.EscapeString(text[t.Start:t.End]))
+		}
+		pos = t.End
+	}
+
+	if to < len(tokens) {
+		b.WriteString(" …")
+	} else {
+		b.WriteString(html.EscapeString(text[pos:]))
+	}
+
+	return template.HTML(b.String())
+}
+
+func unique(l []string) []string {
+	seen := make(map[string]bool, len(l))
+	u := l[:0]
+	for _, s := range l {
+		if !seen[s] {
+			seen[s] = true
+			u = append(u, s)
+		}
+	}
+	return u
+}
@@ -0,0 +1,66 @@
+package search
+
+import (
+	"strings"
+	"testing"
+)
+
+func ids(rs []Result) string {
+	l := make([]string, len(rs))
+	for i, r := range rs {
+		l[i] = r.ID
+	}
+	return strings.Join(l, " ")
+}
+
+func TestSearch(t *testing.T) {
+	idx := New("pt-BR", []Document{
+		{ID: "title", Title: "Ações do governo", Text: "Um texto sobre política."},
+		{ID: "body", Title: "Notícias", Text: "O governo anunciou novas ações."},
+		{ID: "twice", Title: "Mercado", Text: "Uma ação, e depois outra ação."},
+		{ID: "other", Title: "Receitas", Text: "Como fazer pão."},
+	})
+
+	tests := []struct {
+		query string
+		want  string
+	}{
+		// Matches in the title rank above the ones in the body, and more matches
+		// above fewer.
+		{"ação", "title twice body"},
+		{"acoes", "title twice body"},
+		// Documents must have all terms of the query.
+		{"ações governo", "title body"},
+		{"pães", "other"},
+		// The last term is matched as a prefix, as it may be still being typed.
+		{"governo anun", "body"},
+		{"inexistente", ""},
+		{"", ""},
+		{"de e o", ""},
+	}
+
+	for _, tt := range tests {
+		if got := ids(idx.Search(tt.query, 0)); got != tt.want {
+			t.Errorf("Search(%q) = %q, want %q", tt.query, got, tt.want)
+		}
+	}
+
+	if got := ids(idx.Search("ação", 2)); got != "title twice" {
+		t.Errorf("Search() with a limit = %q, want %q", got, "title twice")
+	}
+}
+
+func TestSearchSnippet(t *testing.T) {
+	idx := New("en-US", []Document{
+		{ID: "a", Title: "Go", Text: "Running <code> is what runners do."},
+	})
+
+	rs := idx.Search("run", 0)
+	if len(rs) != 1 {
+		t.Fatalf("Search() = %d results, want 1", len(rs))
+	}
+	want := "<mark>Running</mark> &lt;code&gt; is what <mark>runners</mark> do."
+	if string(rs[0].Snippet) != want {
+		t.Errorf("Snippet = %q, want %q", rs[0].Snippet, want)
+	}
+}
@@ -0,0 +1,139 @@
+package search
+
+import "strings"
+
+// The stemmers below are intentionally light: they only need to map inflections
+// of the same word to the same term, both in the indexed documents and in the
+// queries, not to produce linguistically correct roots.
+
+// stemEN is a reduced version of the Porter stemmer for English words.
+func stemEN(w string) string {
+	if len(w) <= 3 {
+		return w
+	}
+
+	switch {
+	case strings.HasSuffix(w, "sses"):
+		w = w[:len(w)-2]
+	case strings.HasSuffix(w, "ies"):
+		w = w[:len(w)-3] + "y"
+	case strings.HasSuffix(w, "ss"), strings.HasSuffix(w, "us"), strings.HasSuffix(w, "is"):
+	case strings.HasSuffix(w, "s"):
+		w = w[:len(w)-1]
+	}
+
+	for _, s := range []string{"ingly", "edly", "ing", "ed"} {
+		if stem, ok := strings.CutSuffix(w, s); ok && len(stem) >= 3 && hasVowel(stem) {
+			// "tried" has the same stem as "tries" and "trying".
+			if i, ok := strings.CutSuffix(stem, "i"); ok && strings.HasPrefix(s, "ed") {
+				stem = i + "y"
+			}
+			w = undouble(stem)
+			break
+		}
+	}
+
+	for _, s := range [][2]string{
+		{"ational", "ate"},
+		{"ization", "ize"},
+		{"fulness", "ful"},
+		{"iveness", "ive"},
+		{"ousness", "ous"},
+		{"ation", "ate"},
+		{"tion", "t"},
+		{"sion", "s"},
+		{"ness", ""},
+		{"ment", ""},
+		{"ably", "able"},
+		{"ally", "al"},
+		{"ly", ""},
+	} {
+		if stem, ok := strings.CutSuffix(w, s[0]); ok && len(stem) >= 3 {
+			w = stem + s[1]
+			break
+		}
+	}
+
+	w = strings.TrimSuffix(w, "e")
+	if stem, ok := strings.CutSuffix(w, "y"); ok && len(stem) >= 3 {
+		w = stem + "i"
+	}
+
+	return w
+}
+
+// stemPT is a reduced version of the RSLP stemmer for Portuguese words. Words
+// are expected to be already folded, without diacritics.
+func stemPT(w string) string {
+	if len(w) <= 3 {
+		return w
+	}
+
+	// Plural reduction.
+	for _, s := range [][2]string{
+		{"oes", "ao"},
+		{"aes", "ao"},
+		{"ais", "al"},
+		{"eis", "el"},
+		{"ois", "ol"},
+		{"res", "r"},
+		{"ns", "m"},
+		{"s", ""},
+	} {
+		// Plurals of words ending in "ão", such as "pães", may have a single
+		// letter before the suffix.
+		if stem, ok := strings.CutSuffix(w, s[0]); ok && (len(stem) >= 2 || s[1] == "ao") {
+			w = stem + s[1]
+			break
+		}
+	}
+
+	// Adverbs, augmentatives, diminutives and nominal suffixes.
+	nominal := false
+	for _, s := range []string{
+		"amente", "mente",
+		"zinho", "zinha", "inho", "inha", "issimo", "issima",
+		"amento", "imento", "acao", "icao", "idade", "ismo", "ista",
+		"avel", "ivel", "ante", "ente",
+	} {
+		if stem, ok := strings.CutSuffix(w, s); ok && len(stem) >= 3 {
+			w, nominal = stem, true
+			break
+		}
+	}
+
+	// Verbal suffixes, only if the word was not reduced as a noun.
+	for _, s := range []string{
+		"ariam", "eriam", "iriam", "assem", "essem", "issem",
+		"aram", "eram", "iram", "avam", "ando", "endo", "indo",
+		"adas", "idas", "ados", "idos", "ada", "ida", "ado", "ido",
+		"ava", "ria", "ou", "ar", "er", "ir", "am", "em",
+	} {
+		if nominal {
+			break
+		}
+		if stem, ok := strings.CutSuffix(w, s); ok && len(stem) >= 3 {
+			w = stem
+			break
+		}
+	}
+
+	// Gender and thematic vowels.
+	if n := len(w); n > 3 && strings.ContainsRune("aeo", rune(w[n-1])) {
+		w = w[:n-1]
+	}
+
+	return w
+}
+
+func hasVowel(w string) bool {
+	return strings.ContainsAny(w, "aeiouy")
+}
+
+func undouble(w string) string {
+	n := len(w)
+	if n >= 2 && w[n-1] == w[n-2] && !strings.ContainsRune("aeioulsz", rune(w[n-1])) {
+		return w[:n-1]
+	}
+	return w
+}
@@ -0,0 +1,103 @@
+package search
+
+import "testing"
+
+func TestStemEN(t *testing.T) {
+	tests := []struct {
+		word string
+		want string
+	}{
+		// Short words are not stemmed.
+		{"go", "go"},
+		{"is", "is"},
+		{"was", "was"},
+		{"try", "try"},
+		// Words which are only a suffix are not reduced to nothing.
+		{"ing", "ing"},
+		{"ness", "ness"},
+		{"tion", "tion"},
+		{"ment", "ment"},
+
+		{"cats", "cat"},
+		{"boss", "boss"},
+		{"bosses", "boss"},
+		{"caresses", "caress"},
+		{"bus", "bus"},
+		{"running", "run"},
+		{"hopping", "hop"},
+		{"hoped", "hop"},
+		{"generously", "generous"},
+		{"nationalization", "nationaliz"},
+	}
+
+	for _, tt := range tests {
+		if got := stemEN(tt.word); got != tt.want {
+			t.Errorf("stemEN(%q) = %q, want %q", tt.word, got, tt.want)
+		}
+	}
+}
+
+func TestStemPT(t *testing.T) {
+	tests := []struct {
+		word string
+		want string
+	}{
+		// Short words are not stemmed.
+		{"sim", "sim"},
+		{"nao", "nao"},
+		{"ar", "ar"},
+		// Words which are only a suffix are not reduced to nothing.
+		{"mente", "ment"},
+		{"inho", "inh"},
+		{"acao", "aca"},
+
+		{"informacao", "inform"},
+		{"casinha", "cas"},
+		{"rapidamente", "rapid"},
+		{"felicidade", "felic"},
+		{"jornais", "jornal"},
+		{"papeis", "papel"},
+		{"flores", "flor"},
+		{"homens", "hom"},
+	}
+
+	for _, tt := range tests {
+		if got := stemPT(tt.word); got != tt.want {
+			t.Errorf("stemPT(%q) = %q, want %q", tt.word, got, tt.want)
+		}
+	}
+}
+
+// TestStemInflections tests that inflections of the same word, including their
+// accents, share the same term.
+func TestStemInflections(t *testing.T) {
+	tests := []struct {
+		lang  string
+		words []string
+	}{
+		{"en-US", []string{"run", "runs", "running"}},
+		{"en-US", []string{"try", "tries", "tried", "trying"}},
+		{"en-US", []string{"study", "studies", "studied", "studying"}},
+		{"en-US", []string{"happy", "happiness"}},
+		{"en-US", []string{"fly", "flies"}},
+		{"pt-BR", []string{"ação", "ações", "Ação", "acao"}},
+		{"pt-BR", []string{"informação", "informações"}},
+		{"pt-BR", []string{"opção", "opções"}},
+		{"pt-BR", []string{"coração", "corações"}},
+		{"pt-BR", []string{"pão", "pães"}},
+		{"pt-BR", []string{"cão", "cães"}},
+		{"pt-BR", []string{"mão", "mãos"}},
+		{"pt-BR", []string{"gato", "gatos", "gata", "gatas"}},
+		{"pt-BR", []string{"lençol", "lençóis"}},
+		{"pt-BR", []string{"falar", "falando", "falaram"}},
+	}
+
+	for _, tt := range tests {
+		want := Terms(tt.lang, tt.words[0])
+		for _, w := range tt.words[1:] {
+			if got := Terms(tt.lang, w); len(got) != 1 || len(want) != 1 || got[0] != want[0] {
+				t.Errorf("Terms(%q, %q) = %v, want %v as for %q", tt.lang, w, got, want, tt.words[0])
+			}
+		}
+	}
+}
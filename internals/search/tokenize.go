@@ -0,0 +1,122 @@
+package search
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Token is a normalized term and its byte position in the original text.
+type Token struct {
+	Term  string
+	Start int
+	End   int
+}
+
+// Tokenize splits the text into words, folds their case and accents, removes
+// stop words of the language and reduces the words to their stems.
+func Tokenize(lang, text string) []Token {
+	a := analyzerFor(lang)
+
+	var tokens []Token
+	start := -1
+	flush := func(end int) {
+		if start < 0 {
+			return
+		}
+		word := Fold(text[start:end])
+		if !a.stop[word] {
+			if term := a.stem(word); term != "" {
+				tokens = append(tokens, Token{Term: term, Start: start, End: end})
+			}
+		}
+		start = -1
+	}
+
+	for i, r := range text {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		flush(i)
+	}
+	flush(len(text))
+
+	return tokens
+}
+
+// Terms returns only the normalized terms of [Tokenize].
+func Terms(lang, text string) []string {
+	tokens := Tokenize(lang, text)
+	terms := make([]string, len(tokens))
+	for i, t := range tokens {
+		terms[i] = t.Term
+	}
+	return terms
+}
+
+// Fold lower-cases the word and removes diacritics from Latin letters, so
+// "Ação" and "acao" are considered equal.
+func Fold(word string) string {
+	b := new(strings.Builder)
+	b.Grow(len(word))
+	for _, r := range strings.ToLower(word) {
+		if f, ok := folds[r]; ok {
+			b.WriteString(f)
+		} else {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+var folds = map[rune]string{
+	'à': "a", 'á': "a", 'â': "a", 'ã': "a", 'ä': "a", 'å': "a", 'ā': "a",
+	'ç': "c", 'ć': "c", 'č': "c",
+	'è': "e", 'é': "e", 'ê': "e", 'ë': "e", 'ē': "e", 'ę': "e",
+	'ì': "i", 'í': "i", 'î': "i", 'ï': "i", 'ī': "i",
+	'ñ': "n", 'ń': "n",
+	'ò': "o", 'ó': "o", 'ô': "o", 'õ': "o", 'ö': "o", 'ø': "o", 'ō': "o",
+	'ù': "u", 'ú': "u", 'û': "u", 'ü': "u", 'ū': "u",
+	'ý': "y", 'ÿ': "y",
+	'ß': "ss", 'æ': "ae", 'œ': "oe",
+}
+
+type analyzer struct {
+	stop map[string]bool
+	stem func(string) string
+}
+
+func analyzerFor(lang string) analyzer {
+	l, _, _ := strings.Cut(strings.ToLower(lang), "-")
+	switch l {
+	case "pt":
+		return analyzer{stop: stopPT, stem: stemPT}
+	default:
+		return analyzer{stop: stopEN, stem: stemEN}
+	}
+}
+
+func set(words ...string) map[string]bool {
+	m := make(map[string]bool, len(words))
+	for _, w := range words {
+		m[w] = true
+	}
+	return m
+}
+
+var stopEN = set(
+	"a", "an", "and", "are", "as", "at", "be", "but", "by", "for", "from", "has",
+	"have", "if", "in", "into", "is", "it", "its", "of", "on", "or", "our", "so",
+	"that", "the", "their", "then", "there", "these", "they", "this", "to", "was",
+	"we", "were", "will", "with", "you", "your",
+)
+
+var stopPT = set(
+	"a", "ao", "aos", "as", "com", "como", "da", "das", "de", "do", "dos", "e",
+	"ela", "ele", "em", "entre", "era", "essa", "esse", "esta", "este", "eu", "ha",
+	"isso", "isto", "ja", "mais", "mas", "na", "nas", "no", "nos", "o", "os", "ou",
+	"para", "pela", "pelo", "por", "que", "se", "sem", "ser", "seu", "sua", "um",
+	"uma", "voce",
+)
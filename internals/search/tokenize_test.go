@@ -0,0 +1,47 @@
+package search
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFold(t *testing.T) {
+	tests := []struct {
+		word string
+		want string
+	}{
+		{"ação", "acao"},
+		{"AÇÃO", "acao"},
+		{"Informações", "informacoes"},
+		{"naïve", "naive"},
+		{"Straße", "strasse"},
+		{"Œuvre", "oeuvre"},
+		{"plain", "plain"},
+		{"日本", "日本"},
+	}
+
+	for _, tt := range tests {
+		if got := Fold(tt.word); got != tt.want {
+			t.Errorf("Fold(%q) = %q, want %q", tt.word, got, tt.want)
+		}
+	}
+}
+
+func TestTokenize(t *testing.T) {
+	text := "As ações, da Casa!"
+	want := []Token{
+		{Term: "aca", Start: 3, End: 10},
+		{Term: "cas", Start: 15, End: 19},
+	}
+	if got := Tokenize("pt-BR", text); !reflect.DeepEqual(got, want) {
+		t.Errorf("Tokenize(%q) = %+v, want %+v", text, got, want)
+	}
+
+	// Stop words are removed by the rules of each language.
+	if got := Terms("en-US", "the cats and a dog"); !reflect.DeepEqual(got, []string{"cat", "dog"}) {
+		t.Errorf("Terms(en-US) = %v", got)
+	}
+	if got := Terms("pt-BR", "o gato e a casa"); !reflect.DeepEqual(got, []string{"gat", "cas"}) {
+		t.Errorf("Terms(pt-BR) = %v", got)
+	}
+}
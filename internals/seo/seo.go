@@ -0,0 +1,141 @@
+// Package seo describes the metadata of pages used by search engines and social
+// media previews, such as Open Graph, Twitter Cards and JSON-LD.
+package seo
+
+import (
+	"encoding/json"
+	"html/template"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+)
+
+// Page is the metadata of a single page.
+type Page struct {
+	SiteName    string
+	Title       string
+	Description string
+	// URL is the absolute canonical URL of the page.
+	URL string
+	// Lang is the language tag of the page.
+	Lang string
+	// Type is the Open Graph type of the page, "website" or "article".
+	Type string
+	// Image is the absolute URL of the preview image of the page. It must be a
+	// raster image, such as a PNG or JPEG, as SVGs are not shown in previews; see
+	// [PreviewImage].
+	Image     string
+	Author    string
+	Published time.Time
+	Modified  time.Time
+	Tags      []string
+	// Alternates are the translations of the page, including itself.
+	Alternates []Alternate
+}
+
+// Alternate is a translation of a page.
+type Alternate struct {
+	// Lang is the language tag of the translation, or "x-default" for the page
+	// used when no language matches the user's.
+	Lang string
+	URL  string
+}
+
+// Article reports if the page is an article, such as a blog post.
+func (p Page) Article() bool {
+	return p.Type == "article"
+}
+
+// OGLocale returns the page's language in the format used by Open Graph, e.g. "pt_BR".
+func (p Page) OGLocale() string {
+	return ogLocale(p.Lang)
+}
+
+// OGAlternateLocales returns the languages of the page's translations in the
+// format used by Open Graph, excluding its own.
+func (p Page) OGAlternateLocales() []string {
+	var l []string
+	for _, a := range p.Alternates {
+		if a.Lang != p.Lang && a.Lang != "x-default" {
+			l = append(l, ogLocale(a.Lang))
+		}
+	}
+	return l
+}
+
+// PreviewImage returns the page's image, or "" if it is not supported in
+// previews of social media and search engines.
+func (p Page) PreviewImage() string {
+	if !PreviewableImage(p.Image) {
+		return ""
+	}
+	return p.Image
+}
+
+// PreviewableImage reports if the image URL can be used as a preview image,
+// which SVGs can't.
+func PreviewableImage(image string) bool {
+	u, err := url.Parse(image)
+	if err != nil || image == "" {
+		return false
+	}
+	return !strings.EqualFold(path.Ext(u.Path), ".svg")
+}
+
+// JSONLD returns the schema.org structured data of the page, a BlogPosting for
+// articles and a WebPage otherwise.
+func (p Page) JSONLD() template.JS {
+	data := map[string]any{
+		"@context":   "https://schema.org",
+		"@type":      "WebPage",
+		"name":       p.Title,
+		"url":        p.URL,
+		"inLanguage": p.Lang,
+	}
+	if p.Description != "" {
+		data["description"] = p.Description
+	}
+	if i := p.PreviewImage(); i != "" {
+		data["image"] = i
+	}
+	if p.SiteName != "" {
+		data["publisher"] = map[string]any{"@type": "Organization", "name": p.SiteName}
+	}
+
+	if p.Article() {
+		data["@type"] = "BlogPosting"
+		data["headline"] = p.Title
+		data["mainEntityOfPage"] = map[string]any{"@type": "WebPage", "@id": p.URL}
+		if p.Author != "" {
+			data["author"] = map[string]any{"@type": "Person", "name": p.Author}
+		}
+		if !p.Published.IsZero() {
+			data["datePublished"] = p.Published.Format(time.RFC3339)
+		}
+		if !p.Modified.IsZero() {
+			data["dateModified"] = p.Modified.Format(time.RFC3339)
+		}
+		if len(p.Tags) > 0 {
+			data["keywords"] = p.Tags
+		}
+	}
+
+	// encoding/json escapes "<", ">" and "&", so the output is safe to be embedded
+	// in a script element.
+	b, err := json.Marshal(data)
+	if err != nil {
+		return "{}"
+	}
+	return template.JS(b)
+}
+
+func ogLocale(lang string) string {
+	b := []byte(lang)
+	for i, c := range b {
+		if c == '-' {
+			b[i] = '_'
+		}
+	}
+	return string(b)
+}
@@ -0,0 +1,38 @@
+package seo
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPreviewImage(t *testing.T) {
+	tests := []struct {
+		image string
+		want  bool
+	}{
+		{"https://capytal.cc/assets/og-image.png", true},
+		{"https://capytal.cc/blog/cover.JPG?v=2", true},
+		{"https://capytal.cc/assets/icon.svg", false},
+		{"https://capytal.cc/assets/icon.SVG?v=2#top", false},
+		{"/assets/icon.svg", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		if got := PreviewableImage(tt.image); got != tt.want {
+			t.Errorf("PreviewableImage(%q) = %v, want %v", tt.image, got, tt.want)
+		}
+
+		p := Page{Title: "Page", Image: tt.image}
+		want := ""
+		if tt.want {
+			want = tt.image
+		}
+		if got := p.PreviewImage(); got != want {
+			t.Errorf("PreviewImage() of %q = %q, want %q", tt.image, got, want)
+		}
+		if got := strings.Contains(string(p.JSONLD()), `"image"`); got != tt.want {
+			t.Errorf("JSONLD() of %q has an image: %v, want %v", tt.image, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+// Package sitemap encodes sitemaps in the Sitemaps XML format, with support for
+// alternate language versions of each URL.
+package sitemap
+
+import (
+	"encoding/xml"
+	"io"
+	"time"
+)
+
+// ContentType is the content type of a sitemap document.
+const ContentType = "application/xml; charset=utf-8"
+
+// URL is an entry of a sitemap.
+type URL struct {
+	// Loc is the absolute URL of the page.
+	Loc string
+	// LastMod is the time the page was last modified. Optional.
+	LastMod time.Time
+	// Alternates are the language versions of the page, including itself.
+	Alternates []Alternate
+}
+
+// Alternate is a language version of a URL.
+type Alternate struct {
+	// Lang is the language tag of the page, or "x-default".
+	Lang string
+	Href string
+}
+
+type urlset struct {
+	XMLName xml.Name `xml:"urlset"`
+	NS      string   `xml:"xmlns,attr"`
+	XHTMLNS string   `xml:"xmlns:xhtml,attr"`
+	URLs    []url    `xml:"url"`
+}
+
+type url struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod,omitempty"`
+	Links   []link `xml:"xhtml:link"`
+}
+
+type link struct {
+	Rel      string `xml:"rel,attr"`
+	Hreflang string `xml:"hreflang,attr"`
+	Href     string `xml:"href,attr"`
+}
+
+// Write encodes the URLs as a sitemap document.
+func Write(w io.Writer, urls []URL) error {
+	doc := urlset{
+		NS:      "http://www.sitemaps.org/schemas/sitemap/0.9",
+		XHTMLNS: "http://www.w3.org/1999/xhtml",
+		URLs:    make([]url, 0, len(urls)),
+	}
+
+	for _, u := range urls {
+		e := url{Loc: u.Loc}
+		if !u.LastMod.IsZero() {
+			e.LastMod = u.LastMod.UTC().Format(time.RFC3339)
+		}
+		for _, a := range u.Alternates {
+			e.Links = append(e.Links, link{Rel: "alternate", Hreflang: a.Lang, Href: a.Href})
+		}
+		doc.URLs = append(doc.URLs, e)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	e := xml.NewEncoder(w)
+	e.Indent("", "\t")
+	if err := e.Encode(doc); err != nil {
+		return err
+	}
+
+	return e.Close()
+}
@@ -0,0 +1,350 @@
+// Package trace implements spans propagated with the W3C Trace Context
+// "traceparent" header and exported through a pluggable [Exporter].
+package trace
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Header is the name of the W3C Trace Context header.
+const Header = "traceparent"
+
+// TraceID identifies all spans of a trace.
+type TraceID [16]byte
+
+func (id TraceID) String() string { return hex.EncodeToString(id[:]) }
+
+// IsZero reports if the ID is invalid.
+func (id TraceID) IsZero() bool { return id == TraceID{} }
+
+// SpanID identifies a single span of a trace.
+type SpanID [8]byte
+
+func (id SpanID) String() string { return hex.EncodeToString(id[:]) }
+
+// IsZero reports if the ID is invalid.
+func (id SpanID) IsZero() bool { return id == SpanID{} }
+
+// SpanContext is the part of a span propagated to other services.
+type SpanContext struct {
+	TraceID TraceID
+	SpanID  SpanID
+	Sampled bool
+}
+
+// Valid reports if the context has valid trace and span IDs.
+func (sc SpanContext) Valid() bool {
+	return !sc.TraceID.IsZero() && !sc.SpanID.IsZero()
+}
+
+// Traceparent formats the context as the value of a "traceparent" header.
+func (sc SpanContext) Traceparent() string {
+	flags := "00"
+	if sc.Sampled {
+		flags = "01"
+	}
+	return "00-" + sc.TraceID.String() + "-" + sc.SpanID.String() + "-" + flags
+}
+
+// ErrInvalidTraceparent is returned when parsing a malformed "traceparent" value.
+var ErrInvalidTraceparent = errors.New("invalid traceparent")
+
+// ParseTraceparent parses the value of a "traceparent" header. Versions other
+// than "00" are accepted as long as they start with the same fields.
+func ParseTraceparent(s string) (SpanContext, error) {
+	parts := strings.Split(strings.TrimSpace(s), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" || (parts[0] == "00" && len(parts) != 4) {
+		return SpanContext{}, ErrInvalidTraceparent
+	}
+
+	// The lengths are checked first, as hex.Decode panics if the destination
+	// is too short.
+	if len(parts[1]) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 {
+		return SpanContext{}, ErrInvalidTraceparent
+	}
+
+	var sc SpanContext
+	if _, err := hex.Decode(sc.TraceID[:], []byte(parts[1])); err != nil {
+		return SpanContext{}, ErrInvalidTraceparent
+	}
+	if _, err := hex.Decode(sc.SpanID[:], []byte(parts[2])); err != nil {
+		return SpanContext{}, ErrInvalidTraceparent
+	}
+
+	var flags [1]byte
+	if _, err := hex.Decode(flags[:], []byte(parts[3])); err != nil {
+		return SpanContext{}, ErrInvalidTraceparent
+	}
+	sc.Sampled = flags[0]&1 == 1
+
+	if !sc.Valid() {
+		return SpanContext{}, ErrInvalidTraceparent
+	}
+	return sc, nil
+}
+
+// Span is a timed operation of a trace.
+type Span struct {
+	Name     string
+	Context  SpanContext
+	ParentID SpanID
+	Start    time.Time
+	End      time.Time
+	Attrs    map[string]string
+	Err      error
+
+	tracer *Tracer
+	mu     sync.Mutex
+	ended  bool
+}
+
+// SetAttr sets an attribute of the span.
+func (s *Span) SetAttr(key, value string) {
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.Attrs == nil {
+		s.Attrs = map[string]string{}
+	}
+	s.Attrs[key] = value
+}
+
+// SetError marks the span as failed with the error.
+func (s *Span) SetError(err error) {
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.Err = err
+}
+
+// Finish ends the span and exports it, if it is sampled. Calling Finish more
+// than once has no effect.
+func (s *Span) Finish() {
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.End = time.Now()
+	s.mu.Unlock()
+
+	if s.Context.Sampled && s.tracer != nil && s.tracer.exporter != nil {
+		s.tracer.exporter.Export(s)
+	}
+}
+
+// Exporter receives the spans that finished.
+type Exporter interface {
+	Export(s *Span)
+}
+
+// Tracer creates spans exported to its exporter.
+type Tracer struct {
+	exporter Exporter
+}
+
+// NewTracer creates a tracer exporting spans to the exporter. A nil exporter
+// discards all spans, but their contexts are still propagated.
+func NewTracer(e Exporter) *Tracer {
+	return &Tracer{exporter: e}
+}
+
+// Start starts a span of the trace of parent, or a new trace if parent is not
+// valid, and returns a context containing it.
+func (t *Tracer) Start(ctx context.Context, name string, parent SpanContext) (context.Context, *Span) {
+	s := &Span{Name: name, Start: time.Now(), tracer: t}
+
+	if parent.Valid() {
+		s.Context.TraceID = parent.TraceID
+		s.Context.Sampled = parent.Sampled
+		s.ParentID = parent.SpanID
+	} else {
+		_, _ = rand.Read(s.Context.TraceID[:])
+		s.Context.Sampled = true
+	}
+	_, _ = rand.Read(s.Context.SpanID[:])
+
+	return context.WithValue(ctx, spanKey{}, s), s
+}
+
+type spanKey struct{}
+
+// FromContext returns the current span of the context, or nil if there is none.
+func FromContext(ctx context.Context) *Span {
+	s, _ := ctx.Value(spanKey{}).(*Span)
+	return s
+}
+
+// Start starts a child span of the current span of the context, using its tracer.
+// If the context has no span, the returned span is nil, which is safe to use and
+// does nothing.
+func Start(ctx context.Context, name string) (context.Context, *Span) {
+	parent := FromContext(ctx)
+	if parent == nil {
+		return ctx, nil
+	}
+	return parent.tracer.Start(ctx, name, parent.Context)
+}
+
+type (
+	loggerKey    struct{}
+	requestIDKey struct{}
+)
+
+// ContextWithRequestID returns a context carrying the ID of the request.
+func ContextWithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID returns the ID of the request of the context, if any.
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// ContextWithLogger returns a context carrying the request-scoped logger l.
+func ContextWithLogger(ctx context.Context, l *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, l)
+}
+
+// Logger returns the request-scoped logger of the context, or fallback if there
+// is none.
+func Logger(ctx context.Context, fallback *slog.Logger) *slog.Logger {
+	if l, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok && l != nil {
+		return l
+	}
+	return fallback
+}
+
+// Inherit returns ctx with the span, request ID and logger of from, for work
+// done on behalf of the request of from that has its own deadline and
+// cancellation.
+func Inherit(ctx, from context.Context) context.Context {
+	if s := FromContext(from); s != nil {
+		ctx = context.WithValue(ctx, spanKey{}, s)
+	}
+	if id := RequestID(from); id != "" {
+		ctx = ContextWithRequestID(ctx, id)
+	}
+	if l := Logger(from, nil); l != nil {
+		ctx = ContextWithLogger(ctx, l)
+	}
+	return ctx
+}
+
+// Handler is a [slog.Handler] adding the request and trace IDs of the context
+// of each record, for loggers of libraries that log with the context of the
+// request but can't be given the request-scoped logger.
+type Handler struct {
+	slog.Handler
+}
+
+// NewHandler wraps h.
+func NewHandler(h slog.Handler) *Handler {
+	return &Handler{h}
+}
+
+// Handle implements [slog.Handler].
+func (h *Handler) Handle(ctx context.Context, r slog.Record) error {
+	if id := RequestID(ctx); id != "" {
+		r.AddAttrs(slog.String("request_id", id))
+	}
+	if s := FromContext(ctx); s != nil {
+		r.AddAttrs(slog.String("trace_id", s.Context.TraceID.String()))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+// WithAttrs implements [slog.Handler].
+func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &Handler{h.Handler.WithAttrs(attrs)}
+}
+
+// WithGroup implements [slog.Handler].
+func (h *Handler) WithGroup(name string) slog.Handler {
+	return &Handler{h.Handler.WithGroup(name)}
+}
+
+// MemoryExporter keeps the exported spans in memory, to be inspected by tests.
+type MemoryExporter struct {
+	mu    sync.Mutex
+	spans []*Span
+}
+
+// Export implements [Exporter].
+func (e *MemoryExporter) Export(s *Span) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.spans = append(e.spans, s)
+}
+
+// Spans returns the exported spans, in the order they finished.
+func (e *MemoryExporter) Spans() []*Span {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return append([]*Span(nil), e.spans...)
+}
+
+// Reset discards the exported spans.
+func (e *MemoryExporter) Reset() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.spans = nil
+}
+
+// LogExporter writes the spans as debug log records.
+type LogExporter struct {
+	log *slog.Logger
+}
+
+// NewLogExporter creates an exporter writing to the logger.
+func NewLogExporter(l *slog.Logger) *LogExporter {
+	return &LogExporter{log: l}
+}
+
+// Export implements [Exporter].
+func (e *LogExporter) Export(s *Span) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	attrs := []any{
+		slog.String("name", s.Name),
+		slog.String("trace_id", s.Context.TraceID.String()),
+		slog.String("span_id", s.Context.SpanID.String()),
+		slog.Duration("duration", s.End.Sub(s.Start)),
+	}
+	if !s.ParentID.IsZero() {
+		attrs = append(attrs, slog.String("parent_id", s.ParentID.String()))
+	}
+	for k, v := range s.Attrs {
+		attrs = append(attrs, slog.String(k, v))
+	}
+	if s.Err != nil {
+		attrs = append(attrs, slog.String("error", s.Err.Error()))
+	}
+
+	e.log.Debug("Span finished", attrs...)
+}
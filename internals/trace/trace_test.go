@@ -0,0 +1,151 @@
+package trace
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestParseTraceparent(t *testing.T) {
+	tests := []struct {
+		name    string
+		header  string
+		want    string
+		sampled bool
+		err     bool
+	}{
+		{"sampled", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", true, false},
+		{"not sampled", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00", false, false},
+		{"surrounding spaces", " 00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01 ", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", true, false},
+		{"future version with more fields", "01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", true, false},
+
+		{"empty", "", "", false, true},
+		{"version ff", "ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", "", false, true},
+		{"version 00 with more fields", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra", "", false, true},
+		{"zero trace ID", "00-00000000000000000000000000000000-00f067aa0ba902b7-01", "", false, true},
+		{"zero span ID", "00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01", "", false, true},
+		{"short trace ID", "00-4bf92f3577b34da6a3ce929d0e0e47-00f067aa0ba902b7-01", "", false, true},
+		{"long span ID", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b700-01", "", false, true},
+		{"not hexadecimal", "00-4bf92f3577b34da6a3ce929d0e0e47zz-00f067aa0ba902b7-01", "", false, true},
+		{"bad flags", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-1", "", false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sc, err := ParseTraceparent(tt.header)
+			if tt.err {
+				if !errors.Is(err, ErrInvalidTraceparent) {
+					t.Fatalf("ParseTraceparent(%q) error = %v, want ErrInvalidTraceparent", tt.header, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseTraceparent(%q) error = %v", tt.header, err)
+			}
+			if got := sc.Traceparent(); got != tt.want {
+				t.Errorf("Traceparent() = %q, want %q", got, tt.want)
+			}
+			if sc.Sampled != tt.sampled {
+				t.Errorf("Sampled = %v, want %v", sc.Sampled, tt.sampled)
+			}
+		})
+	}
+}
+
+func TestChildSpans(t *testing.T) {
+	exp := &MemoryExporter{}
+	tracer := NewTracer(exp)
+
+	parent, err := ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, root := tracer.Start(context.Background(), "root", parent)
+	ctx, child := Start(ctx, "child")
+	_, grandchild := Start(ctx, "grandchild")
+
+	grandchild.Finish()
+	child.Finish()
+	root.Finish()
+	root.Finish()
+
+	spans := exp.Spans()
+	if len(spans) != 3 {
+		t.Fatalf("exported %d spans, want 3", len(spans))
+	}
+	for i, name := range []string{"grandchild", "child", "root"} {
+		if spans[i].Name != name {
+			t.Errorf("span %d is %q, want %q", i, spans[i].Name, name)
+		}
+		if spans[i].Context.TraceID != parent.TraceID {
+			t.Errorf("span %q has trace ID %s, want %s", name, spans[i].Context.TraceID, parent.TraceID)
+		}
+		if spans[i].End.Before(spans[i].Start) {
+			t.Errorf("span %q ends before it starts", name)
+		}
+	}
+
+	if root.ParentID != parent.SpanID {
+		t.Errorf("root parent = %s, want %s", root.ParentID, parent.SpanID)
+	}
+	if child.ParentID != root.Context.SpanID {
+		t.Errorf("child parent = %s, want %s", child.ParentID, root.Context.SpanID)
+	}
+	if grandchild.ParentID != child.Context.SpanID {
+		t.Errorf("grandchild parent = %s, want %s", grandchild.ParentID, child.Context.SpanID)
+	}
+}
+
+func TestNewTrace(t *testing.T) {
+	exp := &MemoryExporter{}
+
+	_, s := NewTracer(exp).Start(context.Background(), "root", SpanContext{})
+	s.Finish()
+
+	if !s.Context.Valid() || !s.Context.Sampled {
+		t.Errorf("new trace context = %+v, want a valid and sampled context", s.Context)
+	}
+	if !s.ParentID.IsZero() {
+		t.Errorf("root of a new trace has parent %s", s.ParentID)
+	}
+	if n := len(exp.Spans()); n != 1 {
+		t.Errorf("exported %d spans, want 1", n)
+	}
+}
+
+func TestUnsampledSpansAreNotExported(t *testing.T) {
+	exp := &MemoryExporter{}
+
+	parent, err := ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, root := NewTracer(exp).Start(context.Background(), "root", parent)
+	_, child := Start(ctx, "child")
+	child.Finish()
+	root.Finish()
+
+	if spans := exp.Spans(); len(spans) != 0 {
+		t.Errorf("exported %d unsampled spans, want none", len(spans))
+	}
+	if child.Context.Sampled {
+		t.Error("child of an unsampled span is sampled")
+	}
+}
+
+func TestStartWithoutSpan(t *testing.T) {
+	ctx, s := Start(context.Background(), "orphan")
+	if s != nil {
+		t.Fatalf("Start without a span in the context = %+v, want nil", s)
+	}
+	if FromContext(ctx) != nil {
+		t.Error("context has a span")
+	}
+
+	// Nil spans are safe to use.
+	s.SetAttr("key", "value")
+	s.SetError(errors.New("error"))
+	s.Finish()
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"capytal.cc/locales"
+)
+
+// langCookie is the name of the cookie that stores the language explicitly chosen
+// by the user.
+const langCookie = "lang"
+
+// langCookieMaxAge is how long the chosen language is remembered.
+const langCookieMaxAge = 365 * 24 * time.Hour
+
+// langRedirect makes sure every page has a "lang" query parameter, so pages and
+// their caches are always per-language. Requests without it are redirected to the
+// language previously chosen by the user, stored in a cookie, or else the best
+// match of the Accept-Language header. An explicit and supported "lang" parameter
+// is stored as the user's choice for later visits.
+func langRedirect(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		registry := locales.Registry()
+
+		lang := r.URL.Query().Get("lang")
+		if lang == "" {
+			l := registry.Negotiate(r.Header.Get("Accept-Language"))
+			if c, err := r.Cookie(langCookie); err == nil {
+				if cl, ok := registry.Lookup(c.Value); ok {
+					l = cl
+				}
+			}
+
+			q := r.URL.Query()
+			q.Set("lang", l.Tag)
+			u := *r.URL
+			u.RawQuery = q.Encode()
+
+			w.Header().Add("Vary", "Accept-Language")
+			w.Header().Add("Vary", "Cookie")
+			http.Redirect(w, r, u.RequestURI(), http.StatusSeeOther)
+			return
+		}
+
+		if l, ok := registry.Lookup(lang); ok {
+			if c, err := r.Cookie(langCookie); err != nil || c.Value != l.Tag {
+				http.SetCookie(w, &http.Cookie{
+					Name:     langCookie,
+					Value:    l.Tag,
+					Path:     "/",
+					MaxAge:   int(langCookieMaxAge.Seconds()),
+					HttpOnly: true,
+					SameSite: http.SameSiteLaxMode,
+				})
+			}
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestLangRedirect(t *testing.T) {
+	h := langRedirect(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	tests := []struct {
+		name     string
+		uri      string
+		cookie   string
+		accept   string
+		status   int
+		location string
+		set      string
+	}{
+		{"explicit", "/blog/?lang=pt-BR", "", "", http.StatusOK, "", "pt-BR"},
+		{"explicit case insensitive", "/blog/?lang=pt-br", "en-US", "", http.StatusOK, "", "pt-BR"},
+		{"explicit same as cookie", "/blog/?lang=pt-BR", "pt-BR", "", http.StatusOK, "", ""},
+		{"unsupported", "/blog/?lang=xx", "", "", http.StatusOK, "", ""},
+		{"cookie", "/blog/", "pt-BR", "en-US", http.StatusSeeOther, "/blog/?lang=pt-BR", ""},
+		{"accept language", "/blog/", "", "pt-PT, en;q=0.5", http.StatusSeeOther, "/blog/?lang=pt-BR", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, tt.uri, nil)
+			if tt.cookie != "" {
+				r.AddCookie(&http.Cookie{Name: langCookie, Value: tt.cookie})
+			}
+			if tt.accept != "" {
+				r.Header.Set("Accept-Language", tt.accept)
+			}
+
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+
+			if w.Code != tt.status {
+				t.Errorf("status = %d, want %d", w.Code, tt.status)
+			}
+			if l := w.Header().Get("Location"); l != tt.location {
+				t.Errorf("Location = %q, want %q", l, tt.location)
+			}
+
+			var set string
+			for _, c := range w.Result().Cookies() {
+				if c.Name == langCookie {
+					set = c.Value
+				}
+			}
+			if set != tt.set {
+				t.Errorf("stored language = %q, want %q", set, tt.set)
+			}
+		})
+	}
+}
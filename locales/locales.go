@@ -0,0 +1,33 @@
+package locales
+
+import (
+	"embed"
+	"io/fs"
+	"sync"
+
+	"capytal.cc/internals/i18n"
+)
+
+// Fallback is the language used for requests and messages without a supported
+// translation.
+const Fallback = "en-US"
+
+//go:embed *.json
+var files embed.FS
+
+func Files() fs.FS {
+	return files
+}
+
+var registry = sync.OnceValue(func() *i18n.Registry {
+	r, err := i18n.Load(files, Fallback)
+	if err != nil {
+		panic(err)
+	}
+	return r
+})
+
+// Registry returns the languages and message catalogs embedded in the binary.
+func Registry() *i18n.Registry {
+	return registry()
+}
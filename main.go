@@ -7,20 +7,39 @@ import (
 	"fmt"
 	"log/slog"
 	"net/http"
+	"net/url"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"strings"
 	"syscall"
+	"time"
 
+	"capytal.cc/assets"
+	"capytal.cc/internals/trace"
 	"capytal.cc/templates"
 	"capytal.cc/tinyssert"
 )
 
+// The flags override the configuration file and environment variables only if
+// they are set, so their defaults are only shown in the usage message.
 var (
-	hostname     = flag.String("hostname", "localhost", "Host to listen to")
-	port         = flag.Uint("port", 8080, "Port to be used for the server.")
-	templatesDir = flag.String("templates", "", "Templates directory to be used instead of built-in ones.")
-	verbose      = flag.Bool("verbose", false, "Print debug information on logs")
-	dev          = flag.Bool("dev", false, "Run the server in debug mode.")
+	configFile = flag.String("config", getEnv("CAPYTAL_CONFIG", ""), "YAML or JSON configuration file. Environment variables and flags take precedence over it. Defaults to the CAPYTAL_CONFIG environment variable.")
+	_          = flag.String("hostname", "localhost", "Host to listen to")
+	_          = flag.Uint("port", 8080, "Port to be used for the server.")
+	_          = flag.String("base-url", "https://capytal.cc", "Public URL of the site, used in links to it.")
+	_          = flag.String("templates", "", "Templates directory to be used instead of built-in ones.")
+	_          = flag.String("blogs", "", "JSON or YAML file with the list of blogs, and their languages and sources, to be used instead of the default ones.")
+	_          = flag.String("blog-dir", "", "Directory to read blog posts from instead of the forge. Posts of each language are read from a sub-directory named by the language tag (e.g. \"pt-BR\"), if it exists.")
+	_          = flag.String("pages", "", "JSON or YAML file with the list of markdown pages, and their routes and sources, to be used instead of the default ones.")
+	_          = flag.String("pages-dir", "", "Directory to read the markdown pages with local sources from.")
+	_          = flag.String("forge", DefaultForge, "Base URL of the forge of the blogs without one and of the default pages.")
+	_          = flag.String("privacy-policy", "", "Source of the privacy policy page, to be used instead of the default one.")
+	_          = flag.String("trusted-domains", strings.Join(DefaultTrustedDomains, ","), "Comma-separated list of domains links to which are not marked as external.")
+	_          = flag.String("highlight-style", DefaultHighlightStyle, "Chroma style of the code blocks of blog posts and pages.")
+	_          = flag.Bool("verbose", false, "Print debug information on logs")
+	_          = flag.Bool("dev", false, "Run the server in debug mode.")
+	preview    = flag.String("preview", "", "Print the preview URLs of the blog post with the given file name, signed with the preview secret, and exit.")
 )
 
 func getEnv(key string, d string) string {
@@ -31,24 +50,28 @@ func getEnv(key string, d string) string {
 	return v
 }
 
-func init() {
+func main() {
 	flag.Parse()
-}
 
-func main() {
 	ctx := context.Background()
 
+	cfg, err := loadConfig(*configFile, flag.CommandLine)
+	if err != nil {
+		slog.Error("Invalid configuration", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+
 	assertions := tinyssert.NewDisabledAssertions()
-	if *dev {
+	if cfg.Dev {
 		assertions = tinyssert.NewAssertions(tinyssert.Opts{
 			Panic: true,
 		})
 	}
 
 	level := slog.LevelError
-	if *dev {
+	if cfg.Dev {
 		level = slog.LevelDebug
-	} else if *verbose {
+	} else if cfg.Verbose {
 		level = slog.LevelInfo
 	}
 	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: level}))
@@ -56,11 +79,101 @@ func main() {
 	opts := []Option{
 		WithAssertions(assertions),
 		WithLogger(log),
+		WithBaseURL(cfg.BaseURL),
+		WithBlogs(cfg.Blogs...),
+		WithPages(cfg.Pages...),
+		WithTrustedDomains(cfg.TrustedDomains...),
+		WithHighlightStyle(cfg.HighlightStyle),
 	}
-	if *dev {
-		opts = append(opts, WithAssets(os.DirFS("./assets")))
+	assetsFS := assets.Files()
+	if cfg.Dev {
+		assetsFS = os.DirFS("./assets")
+		opts = append(opts, WithAssets(assetsFS))
 		opts = append(opts, WithTemplates(templates.NewHotTemplates(os.DirFS("./templates"))))
 		opts = append(opts, WithCacheDisabled())
+		opts = append(opts, WithDevelopment())
+		opts = append(opts, WithSpanExporter(trace.NewLogExporter(log.WithGroup("trace"))))
+	}
+	if cfg.Templates != "" {
+		opts = append(opts, WithTemplates(templates.NewHotTemplates(os.DirFS(cfg.Templates))))
+	}
+
+	previewSecret := []byte(cfg.PreviewSecret)
+	if len(previewSecret) > 0 {
+		opts = append(opts, WithPreviewSecret(previewSecret))
+	}
+
+	if cfg.WebhookSecret != "" {
+		opts = append(opts, WithWebhookSecret([]byte(cfg.WebhookSecret)))
+	}
+
+	if cfg.AdminToken != "" {
+		opts = append(opts, WithAdminToken(cfg.AdminToken))
+	}
+
+	if *preview != "" {
+		if len(previewSecret) == 0 {
+			log.Error("A preview secret must be configured to sign preview URLs")
+			os.Exit(1)
+		}
+
+		expires := time.Now().Add(previewTokenTTL)
+		for _, b := range cfg.Blogs {
+			fmt.Printf("%s/blog/%s?lang=%s&preview=%s\n",
+				strings.TrimSuffix(cfg.BaseURL, "/"), url.PathEscape(*preview), url.QueryEscape(b.Lang),
+				signPreview(previewSecret, b.Lang, *preview, expires))
+		}
+		os.Exit(0)
+	}
+
+	if cfg.PagesDir != "" {
+		opts = append(opts, WithPagesSource(os.DirFS(cfg.PagesDir)))
+	}
+
+	if cfg.BlogDir != "" {
+		for _, b := range cfg.Blogs {
+			dir := filepath.Join(cfg.BlogDir, b.Lang)
+			if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+				dir = cfg.BlogDir
+			}
+			opts = append(opts, WithBlogSource(b.Lang, os.DirFS(dir)))
+		}
+	}
+
+	if flag.Arg(0) == "export" {
+		cmd := flag.NewFlagSet("export", flag.ExitOnError)
+		out := cmd.String("out", "public", "Directory to write the static site to. Existing files are overwritten, but not removed.")
+		expires := cmd.String("expires", "", "Expiration date, in the \"2006-01-02\" format, of the exported security.txt. Defaults to a year after the newest post.")
+		_ = cmd.Parse(flag.Args()[1:])
+
+		// Exports of the same sources must be identical, so no date may come
+		// from the time of the export or of the checkout of the sources.
+		exportOpts := append(opts, WithCacheDisabled(), WithSourceDates())
+		if *expires != "" {
+			t, err := time.Parse(time.DateOnly, *expires)
+			if err != nil {
+				log.Error("Invalid expiration date", slog.String("error", err.Error()))
+				os.Exit(1)
+			}
+			exportOpts = append(exportOpts, WithSecurityExpires(t))
+		}
+
+		app, err := NewApp(exportOpts...)
+		if err != nil {
+			log.Error("Unable to initiate application", slog.String("error", err.Error()))
+			os.Exit(1)
+		}
+
+		paths := make([]string, len(cfg.Pages))
+		for i, p := range cfg.Pages {
+			paths[i] = p.Path
+		}
+
+		if err := exportSite(app, assetsFS, *out, cfg.BaseURL, paths, log); err != nil {
+			log.Error("Failed to export site", slog.String("error", err.Error()))
+			os.Exit(1)
+		}
+		os.Exit(0)
 	}
 
 	app, err := NewApp(opts...)
@@ -70,7 +183,7 @@ func main() {
 	}
 
 	srv := &http.Server{
-		Addr:    fmt.Sprintf("%s:%d", *hostname, *port),
+		Addr:    fmt.Sprintf("%s:%d", cfg.Hostname, cfg.Port),
 		Handler: app,
 	}
 
@@ -79,10 +192,10 @@ func main() {
 
 	go func() {
 		log.Info("Starting application",
-			slog.String("host", *hostname),
-			slog.Uint64("port", uint64(*port)),
-			slog.Bool("verbose", *verbose),
-			slog.Bool("development", *dev))
+			slog.String("host", cfg.Hostname),
+			slog.Uint64("port", uint64(cfg.Port)),
+			slog.Bool("verbose", cfg.Verbose),
+			slog.Bool("development", cfg.Dev))
 
 		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
 			log.Error("Failed to start application server", slog.String("error", err.Error()))
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"net/url"
+
+	"capytal.cc/internals/seo"
+	"capytal.cc/locales"
+)
+
+// defaultImage is the path of the preview image of pages without their own, a
+// 1200×630 PNG of the logo, the size recommended for Open Graph previews.
+const defaultImage = "/assets/og-image.png"
+
+// pageMeta returns the base metadata of the page at the path in the language,
+// with all supported languages as its translations.
+func (app *app) pageMeta(path, lang, title string) seo.Page {
+	return seo.Page{
+		SiteName:   "Capytal",
+		Title:      title,
+		URL:        app.pageURL(path, lang),
+		Lang:       lang,
+		Type:       "website",
+		Image:      app.baseURL + defaultImage,
+		Alternates: app.alternates(path),
+	}
+}
+
+// pageURL returns the absolute URL of the path in the language.
+func (app *app) pageURL(path, lang string) string {
+	u := app.baseURL + path
+	if lang != "" {
+		u += "?lang=" + url.QueryEscape(lang)
+	}
+	return u
+}
+
+func (app *app) alternates(path string, langs ...string) []seo.Alternate {
+	if len(langs) == 0 {
+		for _, l := range locales.Registry().Languages() {
+			langs = append(langs, l.Tag)
+		}
+	}
+
+	alts := make([]seo.Alternate, 0, len(langs)+1)
+	for _, l := range langs {
+		alts = append(alts, seo.Alternate{Lang: l, URL: app.pageURL(path, l)})
+	}
+	return append(alts, seo.Alternate{Lang: "x-default", URL: app.pageURL(path, "")})
+}
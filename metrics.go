@@ -0,0 +1,187 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"capytal.cc/internals/httpcache"
+	"capytal.cc/internals/metrics"
+	"capytal.cc/templates"
+)
+
+type appMetrics struct {
+	registry *metrics.Registry
+
+	requests        *metrics.CounterVec
+	requestDuration *metrics.HistogramVec
+
+	markdownDuration *metrics.HistogramVec
+	templateDuration *metrics.HistogramVec
+
+	upstreamDuration *metrics.HistogramVec
+	upstreamErrors   *metrics.CounterVec
+	blogLoadDuration *metrics.HistogramVec
+	blogLoadErrors   *metrics.CounterVec
+}
+
+// renderBuckets are the histogram buckets, in seconds, of rendering markdown and
+// templates, which are usually faster than whole requests.
+var renderBuckets = []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1}
+
+func newAppMetrics() *appMetrics {
+	r := metrics.New()
+	return &appMetrics{
+		registry: r,
+
+		requests: r.Counter("capytal_http_requests_total",
+			"Number of HTTP requests handled, by route pattern, method and status code.",
+			"route", "method", "status"),
+		requestDuration: r.Histogram("capytal_http_request_duration_seconds",
+			"Duration of HTTP requests, by route pattern and status code.",
+			metrics.DefBuckets, "route", "status"),
+
+		markdownDuration: r.Histogram("capytal_markdown_duration_seconds",
+			"Duration of rendering blog posts' markdown, by stage.",
+			renderBuckets, "stage"),
+		templateDuration: r.Histogram("capytal_template_duration_seconds",
+			"Duration of executing templates, by template name.",
+			renderBuckets, "template"),
+
+		upstreamDuration: r.Histogram("capytal_upstream_request_duration_seconds",
+			"Duration of requests to upstream servers, such as the forge, by host and status code.",
+			metrics.DefBuckets, "host", "status"),
+		upstreamErrors: r.Counter("capytal_upstream_request_errors_total",
+			"Number of requests to upstream servers that failed without a response, by host.",
+			"host"),
+		blogLoadDuration: r.Histogram("capytal_blog_load_duration_seconds",
+			"Duration of loading the posts of a blog from its source, by language.",
+			metrics.DefBuckets, "lang"),
+		blogLoadErrors: r.Counter("capytal_blog_load_errors_total",
+			"Number of failed loads of the posts of a blog from its source, by language.",
+			"lang"),
+	}
+}
+
+// metricsHandler serves the metrics to clients authenticated with the admin token, such
+// as Prometheus configured with it as its "bearer_token", like the other
+// administration endpoints. Metrics are not served if no token is set.
+func (app *app) metricsHandler() http.Handler {
+	h := app.metrics.registry.Handler()
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if app.authorizeAdmin(w, r) {
+			h.ServeHTTP(w, r)
+		}
+	})
+}
+
+// registerCache exposes the statistics of the response cache.
+func (m *appMetrics) registerCache(c *httpcache.Cache) {
+	m.registry.CounterFunc("capytal_cache_hits_total",
+		"Number of requests served with fresh responses from the response cache.",
+		func() float64 { return float64(c.Stats().Hits) })
+	m.registry.CounterFunc("capytal_cache_stale_total",
+		"Number of requests served with stale responses from the response cache.",
+		func() float64 { return float64(c.Stats().Stale) })
+	m.registry.CounterFunc("capytal_cache_misses_total",
+		"Number of requests not found in the response cache.",
+		func() float64 { return float64(c.Stats().Misses) })
+	m.registry.GaugeFunc("capytal_cache_entries",
+		"Number of responses in the response cache.",
+		func() float64 { return float64(c.Len()) })
+}
+
+// instrument records the count and duration of the requests of the route.
+func (m *appMetrics) instrument(route string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		sw := &statusWriter{ResponseWriter: w}
+
+		next.ServeHTTP(sw, r)
+
+		status := strconv.Itoa(sw.status)
+		if sw.status == 0 {
+			status = strconv.Itoa(http.StatusOK)
+		}
+		m.requests.Inc(route, methodLabel(r.Method), status)
+		m.requestDuration.Observe(time.Since(start).Seconds(), route, status)
+	})
+}
+
+// methodLabel returns the method as a label value. Methods other than the
+// standard ones are all labeled "other", so clients can't create new series
+// with arbitrary methods.
+func methodLabel(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodConnect, http.MethodOptions, http.MethodTrace:
+		return method
+	}
+	return "other"
+}
+
+// transport records the duration and errors of the requests made with next.
+func (m *appMetrics) transport(next http.RoundTripper) http.RoundTripper {
+	return roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		start := time.Now()
+
+		res, err := next.RoundTrip(r)
+		if err != nil {
+			m.upstreamErrors.Inc(r.URL.Host)
+			return res, err
+		}
+
+		m.upstreamDuration.Observe(time.Since(start).Seconds(), r.URL.Host, strconv.Itoa(res.StatusCode))
+		return res, nil
+	})
+}
+
+// templates records the duration of executing the templates of t.
+func (m *appMetrics) templates(t templates.ITemplate) templates.ITemplate {
+	return &instrumentedTemplates{t, m}
+}
+
+type instrumentedTemplates struct {
+	templates.ITemplate
+	metrics *appMetrics
+}
+
+func (t *instrumentedTemplates) ExecuteTemplate(w io.Writer, name string, data any) error {
+	defer t.metrics.observe(t.metrics.templateDuration, time.Now(), name)
+	return t.ITemplate.ExecuteTemplate(w, name, data)
+}
+
+func (m *appMetrics) observe(h *metrics.HistogramVec, start time.Time, labels ...string) {
+	h.Observe(time.Since(start).Seconds(), labels...)
+}
+
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *statusWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Unwrap allows [http.ResponseController] to access the underlying writer.
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+type roundTripperFunc func(r *http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestInstrumentMethods(t *testing.T) {
+	m := newAppMetrics()
+	h := m.instrument("/blog/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	}))
+
+	for _, method := range []string{"GET", "GET", "POST", "FOO", "BAR1", "get"} {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(method, "/blog/", nil))
+	}
+
+	var b strings.Builder
+	if _, err := m.registry.WriteTo(&b); err != nil {
+		t.Fatal(err)
+	}
+
+	var samples []string
+	for _, l := range strings.Split(b.String(), "\n") {
+		if strings.HasPrefix(l, "capytal_http_requests_total{") {
+			samples = append(samples, l)
+		}
+	}
+	want := []string{
+		`capytal_http_requests_total{route="/blog/",method="GET",status="200"} 2`,
+		`capytal_http_requests_total{route="/blog/",method="POST",status="405"} 1`,
+		`capytal_http_requests_total{route="/blog/",method="other",status="405"} 3`,
+	}
+	if strings.Join(samples, "\n") != strings.Join(want, "\n") {
+		t.Errorf("samples:\n%s\nwant:\n%s", strings.Join(samples, "\n"), strings.Join(want, "\n"))
+	}
+}
@@ -0,0 +1,231 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"capytal.cc/internals/posts"
+	"capytal.cc/internals/remote"
+	"capytal.cc/locales"
+	"github.com/goodsign/monday"
+	"github.com/yuin/goldmark/text"
+)
+
+// PageConfig is a page of the site rendered from a markdown document, such as
+// legal and informational pages.
+type PageConfig struct {
+	// Path is the route of the page, e.g. "/terms/".
+	Path string `json:"path" yaml:"path"`
+
+	// Source is the URL of a remote document, or the path of a document in the
+	// local pages file system if it is not a "http" or "https" URL.
+	Source string `json:"source" yaml:"source"`
+	// Suffix is the format of the suffix added before the extension of the
+	// source to find its translations, formatted with the language tag.
+	// Defaults to "_%s", e.g. "PRIVACY_POLICY_pt-BR.md". The fallback language
+	// uses the source as is.
+	Suffix string `json:"suffix,omitempty" yaml:"suffix,omitempty"`
+
+	// Template is the name of the template used to render the page. Defaults
+	// to "markdown-page".
+	Template string `json:"template,omitempty" yaml:"template,omitempty"`
+	// Title is used if the document has no "title" in its front matter.
+	Title string `json:"title" yaml:"title"`
+	// Modified is the date, in the "2006-01-02" format, used if the document has
+	// no "modified" date in its front matter.
+	Modified string `json:"modified,omitempty" yaml:"modified,omitempty"`
+}
+
+// DefaultPages are the markdown pages served by the application if none is configured.
+var DefaultPages = []PageConfig{{
+	Path:     "/privacy/",
+	Source:   DefaultForge + "/api/v1/repos/capytal/privacy-policy/raw/PRIVACY_POLICY.md",
+	Template: "privacy-policy",
+	Title:    "Privacy Policy",
+	Modified: "2025-04-11",
+}}
+
+// reservedPaths are the built-in routes pages can't be served at. Paths ending
+// in a slash also reserve the paths under them. The about page is not reserved,
+// as a markdown page may replace it.
+var reservedPaths = []string{
+	"/", "/assets/", "/blog/",
+	"/sitemap.xml", "/robots.txt", "/humans.txt", "/.well-known/",
+	"/metrics", "/healthz", "/readyz",
+	"/hooks/", "/admin/",
+}
+
+// validatePath reports if the path of the page is invalid or collides with a
+// built-in route.
+func (c PageConfig) validatePath() error {
+	if !strings.HasPrefix(c.Path, "/") {
+		return fmt.Errorf("path of page %q must start with a slash", c.Path)
+	}
+	for _, r := range reservedPaths {
+		if strings.TrimSuffix(c.Path, "/") == strings.TrimSuffix(r, "/") ||
+			(r != "/" && strings.HasSuffix(r, "/") && strings.HasPrefix(c.Path, r)) {
+			return fmt.Errorf("path of page %q is reserved by the built-in route %q", c.Path, r)
+		}
+	}
+	return nil
+}
+
+// validate reports if the page is invalid, except for its path, validated by
+// [PageConfig.validatePath].
+func (c PageConfig) validate() error {
+	if c.Source == "" {
+		return fmt.Errorf("page %q has no source", c.Path)
+	}
+	if c.Suffix != "" && !strings.Contains(c.Suffix, "%s") {
+		return fmt.Errorf("suffix of page %q must contain the language verb %%s", c.Path)
+	}
+	if c.Modified != "" {
+		if _, err := time.Parse(time.DateOnly, c.Modified); err != nil {
+			return fmt.Errorf("invalid modified date of page %q: %w", c.Path, err)
+		}
+	}
+	return nil
+}
+
+func (c PageConfig) remote() bool {
+	return strings.HasPrefix(c.Source, "https://") || strings.HasPrefix(c.Source, "http://")
+}
+
+// localized returns the source of the page's translation to the language.
+func (c PageConfig) localized(lang string) string {
+	suffix := c.Suffix
+	if suffix == "" {
+		suffix = "_%s"
+	}
+	ext := path.Ext(c.Source)
+	return strings.TrimSuffix(c.Source, ext) + fmt.Sprintf(suffix, lang) + ext
+}
+
+// page serves the markdown page, in the requested language if the document
+// has a translation to it.
+func (app *app) page(c PageConfig) http.Handler {
+	tmpl := c.Template
+	if tmpl == "" {
+		tmpl = "markdown-page"
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lang := r.URL.Query().Get("lang")
+
+		src, err := app.pageDocument(r.Context(), c, lang)
+		if notFound(err) {
+			app.notFound(w, r)
+			return
+		}
+		if err != nil {
+			app.serverError(w, r, err)
+			return
+		}
+
+		doc := app.markdown.Parser().Parse(text.NewReader(src))
+		meta := doc.OwnerDocument().Meta()
+
+		title := c.Title
+		if t, ok := meta["title"].(string); ok {
+			title = t
+		}
+
+		var changeDate time.Time
+		if c.Modified != "" {
+			changeDate, err = time.Parse(time.DateOnly, c.Modified)
+			app.assert.Nil(err, "Modified date should be validated on creation")
+		}
+		if t, err := posts.Time(meta, "modified"); err != nil {
+			app.serverError(w, r, err)
+			return
+		} else if !t.IsZero() {
+			changeDate = t
+		}
+
+		f := new(strings.Builder)
+		err = app.markdown.Renderer().Render(f, src, doc)
+		if err != nil {
+			app.serverError(w, r, err)
+			return
+		}
+
+		locale := lang
+		if locale == "" {
+			locale = locales.Fallback
+		}
+		locale = strings.Replace(locale, "-", "_", 1)
+
+		format, ok := monday.LongFormatsByLocale[monday.Locale(locale)]
+		if !ok {
+			format = time.DateTime
+		}
+
+		page := app.pageMeta(c.Path, lang, title)
+		page.Modified = changeDate
+		if d, ok := meta["description"].(string); ok {
+			page.Description = d
+		}
+
+		data := map[string]any{
+			"Title":   title,
+			"Meta":    page,
+			"Lang":    lang,
+			"Content": template.HTML(f.String()),
+		}
+		if !changeDate.IsZero() {
+			data["ChangeDate"] = monday.Format(changeDate, format, monday.Locale(locale))
+		}
+
+		err = app.templates.ExecuteTemplate(w, tmpl, data)
+		if err != nil {
+			app.serverError(w, r, err)
+			return
+		}
+	})
+}
+
+// pageDocument reads the source of the page in the language, falling back to the
+// untranslated source if the language has no translation.
+func (app *app) pageDocument(ctx context.Context, c PageConfig, lang string) ([]byte, error) {
+	if lang != "" && !strings.EqualFold(lang, locales.Fallback) {
+		src, err := app.readPageSource(ctx, c, c.localized(lang))
+		if !notFound(err) {
+			return src, err
+		}
+	}
+	return app.readPageSource(ctx, c, c.Source)
+}
+
+func (app *app) readPageSource(ctx context.Context, c PageConfig, source string) ([]byte, error) {
+	if c.remote() {
+		doc, err := app.remote.Get(ctx, source)
+		if err != nil && !notFound(err) {
+			return nil, fmt.Errorf("%w: %w", errUnavailable, err)
+		}
+		return doc.Body, err
+	}
+	return fs.ReadFile(app.pagesSource, source)
+}
+
+func notFound(err error) bool {
+	var status *remote.StatusError
+	return errors.Is(err, fs.ErrNotExist) ||
+		errors.As(err, &status) && status.NotFound()
+}
+
+// hasPage reports if a markdown page is configured at the path.
+func (app *app) hasPage(path string) bool {
+	for _, c := range app.pageConfigs {
+		if c.Path == path {
+			return true
+		}
+	}
+	return false
+}
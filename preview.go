@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// previewTokenTTL is how long a generated preview token is valid.
+const previewTokenTTL = 7 * 24 * time.Hour
+
+// signPreview creates a token that allows the not yet published post with the
+// name to be viewed until the expiration time. The token is passed in the
+// "preview" query parameter of the post's URL.
+func signPreview(secret []byte, lang, name string, expires time.Time) string {
+	exp := strconv.FormatInt(expires.Unix(), 36)
+	return exp + "." + base64.RawURLEncoding.EncodeToString(previewMAC(secret, lang, name, exp))
+}
+
+// verifyPreview reports if the token was created by [signPreview] for the post
+// and is not expired. Tokens are never valid if there is no secret.
+func verifyPreview(secret []byte, lang, name, token string, now time.Time) bool {
+	if len(secret) == 0 || token == "" {
+		return false
+	}
+
+	exp, sig, ok := strings.Cut(token, ".")
+	if !ok {
+		return false
+	}
+
+	unix, err := strconv.ParseInt(exp, 36, 64)
+	if err != nil || now.After(time.Unix(unix, 0)) {
+		return false
+	}
+
+	mac, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil {
+		return false
+	}
+
+	return hmac.Equal(mac, previewMAC(secret, lang, name, exp))
+}
+
+func previewMAC(secret []byte, lang, name, exp string) []byte {
+	mac := hmac.New(sha256.New, secret)
+	fmt.Fprintf(mac, "%s\x00%s\x00%s", lang, name, exp)
+	return mac.Sum(nil)
+}
+
+type previewKey struct{}
+
+// withPreview marks the context of a request with a valid preview token, which
+// allows the unpublished post it was signed for to be rendered.
+func withPreview(ctx context.Context) context.Context {
+	return context.WithValue(ctx, previewKey{}, true)
+}
+
+// isPreview reports if the context is of a request with a valid preview token.
+func isPreview(ctx context.Context) bool {
+	ok, _ := ctx.Value(previewKey{}).(bool)
+	return ok
+}
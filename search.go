@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+
+	"capytal.cc/internals/search"
+	"capytal.cc/locales"
+)
+
+// searchResultsLimit is the maximum number of results shown for a query.
+const searchResultsLimit = 20
+
+type searchIndex struct {
+	mu      sync.Mutex
+	index   *search.Index
+	version uint64
+}
+
+// Search queries the full-text index of the blog's posts, rebuilding it if the
+// contents of the blog changed since it was last built.
+func (b *blog) Search(ctx context.Context, query string) ([]search.Result, error) {
+	ps, version, err := b.versionedPosts(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	b.search.mu.Lock()
+	defer b.search.mu.Unlock()
+
+	if b.search.index == nil || b.search.version != version {
+		docs := make([]search.Document, len(ps))
+		for i, p := range ps {
+			docs[i] = search.Document{ID: p.Name, Title: p.Title, Text: p.Text()}
+		}
+
+		b.search.index = search.New(b.lang, docs)
+		b.search.version = version
+	}
+
+	return b.search.index.Search(query, searchResultsLimit), nil
+}
+
+// search serves the search page of the blog. Requests made by htmx, other than
+// boosted navigations, only receive the results fragment.
+func (app *app) search(b *blog) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := strings.TrimSpace(r.URL.Query().Get("q"))
+
+		var results []search.Result
+		if query != "" {
+			var err error
+			results, err = b.Search(r.Context(), query)
+			if err != nil {
+				app.serverError(w, r, err)
+				return
+			}
+		}
+
+		name := "blog-search"
+		if r.Header.Get("HX-Request") == "true" && r.Header.Get("HX-Boosted") != "true" {
+			name = "blog-search-results"
+		}
+
+		w.Header().Add("Vary", "HX-Request")
+		err := app.templates.ExecuteTemplate(w, name, map[string]any{
+			"Lang":    b.lang,
+			"Meta":    app.pageMeta("/blog/search", b.lang, locales.Registry().T(b.lang, "blog.search")),
+			"Query":   query,
+			"Results": results,
+		})
+		if err != nil {
+			app.serverError(w, r, err)
+			return
+		}
+	})
+}
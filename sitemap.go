@@ -0,0 +1,110 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"slices"
+	"sync"
+
+	"capytal.cc/internals/sitemap"
+)
+
+// sitemapPages are the paths of the built-in pages listed in the sitemap, in all
+// supported languages, alongside the configured markdown pages.
+var sitemapPages = []string{"/", "/about/"}
+
+type sitemapCache struct {
+	mu       sync.Mutex
+	versions []uint64
+	doc      []byte
+}
+
+// sitemap serves the sitemap of all pages and blog posts. The document is only
+// regenerated when the contents of any blog change.
+func (app *app) sitemap() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		versions := make([]uint64, len(app.blogs))
+		for i, b := range app.blogs {
+			v, err := b.Version(r.Context())
+			if err != nil {
+				app.serverError(w, r, err)
+				return
+			}
+			versions[i] = v
+		}
+
+		app.sitemapDoc.mu.Lock()
+		defer app.sitemapDoc.mu.Unlock()
+
+		if app.sitemapDoc.doc == nil || !slices.Equal(app.sitemapDoc.versions, versions) {
+			doc, err := app.generateSitemap(r.Context())
+			if err != nil {
+				app.serverError(w, r, err)
+				return
+			}
+			app.sitemapDoc.doc = doc
+			app.sitemapDoc.versions = versions
+		}
+
+		w.Header().Set("Content-Type", sitemap.ContentType)
+		if _, err := w.Write(app.sitemapDoc.doc); err != nil {
+			app.logger(r.Context()).Error("Failed to write sitemap", slog.String("error", err.Error()))
+		}
+	})
+}
+
+func (app *app) generateSitemap(ctx context.Context) ([]byte, error) {
+	var urls []sitemap.URL
+
+	pages := slices.Clone(sitemapPages)
+	for _, c := range app.pageConfigs {
+		if !slices.Contains(pages, c.Path) {
+			pages = append(pages, c.Path)
+		}
+	}
+
+	for _, p := range pages {
+		alts := app.alternates(p)
+		for _, a := range alts {
+			if a.Lang == "x-default" {
+				continue
+			}
+			u := sitemap.URL{Loc: a.URL}
+			for _, a := range alts {
+				u.Alternates = append(u.Alternates, sitemap.Alternate{Lang: a.Lang, Href: a.URL})
+			}
+			urls = append(urls, u)
+		}
+	}
+
+	for _, b := range app.blogs {
+		ps, err := b.Posts(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, p := range ps {
+			ts, err := app.translations(ctx, p)
+			if err != nil {
+				return nil, err
+			}
+
+			u := sitemap.URL{
+				Loc:     app.blogURL(b.lang, p.Name),
+				LastMod: p.Modified,
+			}
+			for _, a := range translationAlternates(ts) {
+				u.Alternates = append(u.Alternates, sitemap.Alternate{Lang: a.Lang, Href: a.URL})
+			}
+			urls = append(urls, u)
+		}
+	}
+
+	buf := new(bytes.Buffer)
+	if err := sitemap.Write(buf, urls); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestSitemap(t *testing.T) {
+	post := func(front string) *fstest.MapFile {
+		return &fstest.MapFile{Data: []byte("---\n" + front + "\n---\n\n# Post\n")}
+	}
+
+	h, err := NewApp(
+		WithPages(),
+		WithCacheDisabled(),
+		WithBlogSource("en-US", fstest.MapFS{
+			"hello.md":  post("date: 2025-01-01\nmodified: 2025-03-04"),
+			"only.md":   post("date: 2025-02-01"),
+			"draft.md":  post("date: 2025-01-01\ndraft: true"),
+			"future.md": post("date: 2025-01-01\npublish: 2999-01-01"),
+		}),
+		WithBlogSource("pt-BR", fstest.MapFS{
+			"hello.md": post("date: 2025-01-01\nmodified: 2025-05-06"),
+			"ola.md":   post("date: 2025-01-01\ntranslationKey: only.md"),
+			"draft.md": post("date: 2025-01-01\ndraft: true"),
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/sitemap.xml", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET /sitemap.xml = %d", w.Code)
+	}
+
+	var doc struct {
+		URLs []struct {
+			Loc     string `xml:"loc"`
+			LastMod string `xml:"lastmod"`
+			Links   []struct {
+				Hreflang string `xml:"hreflang,attr"`
+				Href     string `xml:"href,attr"`
+			} `xml:"http://www.w3.org/1999/xhtml link"`
+		} `xml:"url"`
+	}
+	if err := xml.Unmarshal(w.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("invalid sitemap: %v\n%s", err, w.Body.String())
+	}
+
+	type entry struct {
+		lastmod string
+		links   []string
+	}
+	got := map[string]entry{}
+	for _, u := range doc.URLs {
+		var links []string
+		for _, l := range u.Links {
+			links = append(links, l.Hreflang+"="+l.Href)
+		}
+		slices.Sort(links)
+		got[u.Loc] = entry{u.LastMod, links}
+	}
+
+	want := map[string]entry{
+		"https://capytal.cc/blog/hello.md?lang=en-US": {"2025-03-04T00:00:00Z", []string{
+			"en-US=https://capytal.cc/blog/hello.md?lang=en-US",
+			"pt-BR=https://capytal.cc/blog/hello.md?lang=pt-BR",
+			"x-default=https://capytal.cc/blog/hello.md",
+		}},
+		"https://capytal.cc/blog/hello.md?lang=pt-BR": {"2025-05-06T00:00:00Z", []string{
+			"en-US=https://capytal.cc/blog/hello.md?lang=en-US",
+			"pt-BR=https://capytal.cc/blog/hello.md?lang=pt-BR",
+			"x-default=https://capytal.cc/blog/hello.md",
+		}},
+		// Translations with other names are alternates of each other.
+		"https://capytal.cc/blog/only.md?lang=en-US": {"2025-02-01T00:00:00Z", []string{
+			"en-US=https://capytal.cc/blog/only.md?lang=en-US",
+			"pt-BR=https://capytal.cc/blog/ola.md?lang=pt-BR",
+			"x-default=https://capytal.cc/blog/only.md",
+		}},
+		"https://capytal.cc/blog/ola.md?lang=pt-BR": {"2025-01-01T00:00:00Z", []string{
+			"en-US=https://capytal.cc/blog/only.md?lang=en-US",
+			"pt-BR=https://capytal.cc/blog/ola.md?lang=pt-BR",
+			"x-default=https://capytal.cc/blog/only.md",
+		}},
+	}
+	for loc, e := range want {
+		g, ok := got[loc]
+		if !ok {
+			t.Errorf("sitemap has no %s", loc)
+			continue
+		}
+		if g.lastmod != e.lastmod {
+			t.Errorf("%s lastmod = %s, want %s", loc, g.lastmod, e.lastmod)
+		}
+		if !slices.Equal(g.links, e.links) {
+			t.Errorf("%s alternates = %v, want %v", loc, g.links, e.links)
+		}
+	}
+
+	for loc, e := range got {
+		if strings.Contains(loc, "draft.md") || strings.Contains(loc, "future.md") {
+			t.Errorf("sitemap has the hidden post %s", loc)
+		}
+		// Built-in pages are listed in every language.
+		if !strings.Contains(loc, "/blog/") && len(e.links) != 3 {
+			t.Errorf("%s alternates = %v, want all languages", loc, e.links)
+		}
+	}
+	if len(got) != len(want)+4 {
+		t.Errorf("sitemap has %d URLs, want %d:\n%s", len(got), len(want)+4, w.Body.String())
+	}
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"io/fs"
+
+	"forge.capytal.company/loreddev/blogo/plugin"
+)
+
+// fsSource is a blogo sourcer plugin that serves the posts from a [fs.FS], such
+// as a local directory or an embedded file system.
+type fsSource struct {
+	fsys fs.FS
+}
+
+var _ plugin.Sourcer = (*fsSource)(nil)
+
+func newFSSource(fsys fs.FS) *fsSource {
+	return &fsSource{fsys: fsys}
+}
+
+func (s *fsSource) Name() string {
+	return "capytal-fs-sourcer"
+}
+
+func (s *fsSource) Source() (fs.FS, error) {
+	return s.fsys, nil
+}
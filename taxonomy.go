@@ -0,0 +1,75 @@
+package main
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+
+	"capytal.cc/internals/posts"
+	"capytal.cc/locales"
+)
+
+// taxonomy serves the listing pages of tags and categories of the blog, under
+// the "tags/" and "categories/" paths respectively.
+func (app *app) taxonomy(b *blog) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		kind, term, _ := strings.Cut(strings.TrimSuffix(r.URL.Path, "/"), "/")
+
+		ps, err := b.Posts(r.Context())
+		if err != nil {
+			app.serverError(w, r, err)
+			return
+		}
+
+		if term == "" {
+			terms := posts.Tags(ps)
+			if kind == "categories" {
+				terms = posts.Categories(ps)
+			}
+
+			title := locales.Registry().T(b.lang, "blog."+kind)
+			err = app.templates.ExecuteTemplate(w, "blog-terms", map[string]any{
+				"Lang":  b.lang,
+				"Meta":  app.pageMeta("/blog/"+kind+"/", b.lang, title),
+				"Kind":  kind,
+				"Terms": terms,
+			})
+			if err != nil {
+				app.serverError(w, r, err)
+			}
+			return
+		}
+
+		heading := "#" + term
+		if kind == "categories" {
+			ps, heading = posts.InCategory(ps, term), term
+		} else {
+			ps = posts.WithTag(ps, term)
+		}
+		if len(ps) == 0 {
+			app.notFound(w, r)
+			return
+		}
+
+		summaries := make([]posts.Summary, len(ps))
+		for i, p := range ps {
+			summaries[i] = p.Summary()
+		}
+
+		err = app.templates.ExecuteTemplate(w, "blog", map[string]any{
+			"Lang":    b.lang,
+			"Meta":    app.pageMeta("/blog/"+kind+"/"+url.PathEscape(term), b.lang, heading),
+			"Heading": heading,
+			"Posts":   summaries,
+		})
+		if err != nil {
+			app.serverError(w, r, err)
+			return
+		}
+	})
+}
+
+func isTaxonomyPath(p string) bool {
+	kind, _, _ := strings.Cut(p, "/")
+	return kind == "tags" || kind == "categories"
+}
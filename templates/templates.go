@@ -9,6 +9,12 @@ import (
 	"html/template"
 	"io"
 	"io/fs"
+	"strings"
+	"time"
+
+	"capytal.cc/internals/i18n"
+	"capytal.cc/locales"
+	"github.com/goodsign/monday"
 )
 
 var (
@@ -32,13 +38,72 @@ var (
 
 			return m, nil
 		},
+		"t": func(lang, key string, args ...any) string {
+			return locales.Registry().T(lang, key, args...)
+		},
+		"tHTML": func(lang, key string, args ...any) template.HTML {
+			return locales.Registry().HTML(lang, key, args...)
+		},
+		"languages": func() []i18n.Language {
+			return locales.Registry().Languages()
+		},
+		"language": func(tag string) i18n.Language {
+			if l, ok := locales.Registry().Lookup(tag); ok {
+				return l
+			}
+			return i18n.Language{Tag: tag, Name: tag}
+		},
+		"date": func(t time.Time, lang string) string {
+			locale := monday.Locale(strings.Replace(lang, "-", "_", 1))
+
+			format, ok := monday.MediumFormatsByLocale[locale]
+			if !ok {
+				locale, format = monday.LocaleEnUS, time.DateOnly
+			}
+
+			return monday.Format(t, format, locale)
+		},
 	}
 )
 
 //go:embed *.html layouts/*.html partials/*.html components/*.html
 var embedded embed.FS
 
-var temps = template.Must(template.New("templates").Funcs(functions).ParseFS(embedded, patterns...))
+var temps = template.Must(parse(embedded))
+
+// parse parses the templates of fsys. The "localized" function executes other
+// templates by name, so it is bound to the parsed set of templates.
+func parse(fsys fs.FS) (*template.Template, error) {
+	var t *template.Template
+	localizedFunc := template.FuncMap{
+		"localized": func(name, lang string, data any) (template.HTML, error) {
+			return localized(t, name, lang, data)
+		},
+	}
+
+	t, err := template.New("templates").Funcs(functions).Funcs(localizedFunc).ParseFS(fsys, patterns...)
+	return t, err
+}
+
+// localized executes the translation of a template to the language, the template
+// named by the name and the language tag (e.g. "about-content-pt-BR"), or else
+// the one of the fallback language, so languages without a translation of
+// the template can still be rendered.
+func localized(t *template.Template, name, lang string, data any) (template.HTML, error) {
+	tmpl := t.Lookup(name + "-" + lang)
+	if tmpl == nil {
+		tmpl = t.Lookup(name + "-" + locales.Fallback)
+	}
+	if tmpl == nil {
+		return "", fmt.Errorf("template %q has no translation to %q or %q", name, lang, locales.Fallback)
+	}
+
+	var b strings.Builder
+	if err := tmpl.Execute(&b, data); err != nil {
+		return "", err
+	}
+	return template.HTML(b.String()), nil
+}
 
 func Templates() *template.Template {
 	return temps // TODO: Support for local templates/hot-reloading without rebuild
@@ -56,7 +121,7 @@ type HotTemplate struct {
 }
 
 func (t *HotTemplate) Execute(wr io.Writer, data any) error {
-	te, err := template.New("hot-templates").Funcs(functions).ParseFS(t.fs, patterns...)
+	te, err := parse(t.fs)
 	if err != nil {
 		return err
 	}
@@ -64,7 +129,7 @@ func (t *HotTemplate) Execute(wr io.Writer, data any) error {
 }
 
 func (t *HotTemplate) ExecuteTemplate(wr io.Writer, name string, data any) error {
-	te, err := template.New("hot-templates").Funcs(functions).ParseFS(t.fs, patterns...)
+	te, err := parse(t.fs)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,61 @@
+package templates
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestLocalized(t *testing.T) {
+	fsys := fstest.MapFS{
+		"layouts/page.html":    {Data: []byte(`{{define "page"}}<main>{{localized "content" .Lang .}}</main>{{end}}`)},
+		"content_en-US.html":   {Data: []byte(`{{define "content-en-US"}}<p>Hello, {{.Name}}</p>{{end}}`)},
+		"content_pt-BR.html":   {Data: []byte(`{{define "content-pt-BR"}}<p>Olá, {{.Name}}</p>{{end}}`)},
+		"partials/other.html":  {Data: []byte(`{{define "other"}}{{localized "missing" .Lang .}}{{end}}`)},
+		"components/none.html": {Data: []byte(`{{define "none"}}{{end}}`)},
+	}
+
+	tmpl, err := parse(fsys)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		lang string
+		want string
+	}{
+		{"en-US", "<main><p>Hello, &lt;b&gt;</p></main>"},
+		{"pt-BR", "<main><p>Olá, &lt;b&gt;</p></main>"},
+		// Languages without a translation of the template use the fallback one.
+		{"es-ES", "<main><p>Hello, &lt;b&gt;</p></main>"},
+		{"", "<main><p>Hello, &lt;b&gt;</p></main>"},
+	}
+	for _, tt := range tests {
+		var b strings.Builder
+		err := tmpl.ExecuteTemplate(&b, "page", map[string]any{"Lang": tt.lang, "Name": "<b>"})
+		if err != nil {
+			t.Errorf("ExecuteTemplate(%q) error = %v", tt.lang, err)
+			continue
+		}
+		if b.String() != tt.want {
+			t.Errorf("ExecuteTemplate(%q) = %q, want %q", tt.lang, b.String(), tt.want)
+		}
+	}
+
+	var b strings.Builder
+	if err := tmpl.ExecuteTemplate(&b, "other", map[string]any{"Lang": "en-US"}); err == nil {
+		t.Error("template without translations executed without error")
+	}
+}
+
+func TestAboutLanguages(t *testing.T) {
+	for _, lang := range []string{"en-US", "pt-BR", "es-ES"} {
+		var b strings.Builder
+		if err := Templates().ExecuteTemplate(&b, "about", map[string]any{"Lang": lang}); err != nil {
+			t.Fatalf("about page in %q: %v", lang, err)
+		}
+		if !strings.Contains(b.String(), "contact@capytal.cc") {
+			t.Errorf("about page in %q has no content", lang)
+		}
+	}
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"capytal.cc/internals/trace"
+)
+
+// requestIDHeader is the header the ID of a request is read from, if set by a
+// proxy, and returned in.
+const requestIDHeader = "X-Request-ID"
+
+// traced assigns an ID to each request of the route, or keeps the one set by a
+// proxy, and continues the trace of its "traceparent" header, if any. The
+// context of the request carries the span of the request and a logger with the
+// request and trace IDs, so the request can be followed through the handlers,
+// renderers and upstream requests it causes. Each request is logged once it is
+// served, with the same IDs.
+func (app *app) traced(route string, next http.Handler) http.Handler {
+	access := app.log.WithGroup("requests")
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+
+		id := r.Header.Get(requestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+
+		parent, _ := trace.ParseTraceparent(r.Header.Get(trace.Header))
+		ctx, span := app.tracer.Start(r.Context(), r.Method+" "+route, parent)
+		defer span.Finish()
+
+		span.SetAttr("http.method", r.Method)
+		span.SetAttr("http.route", route)
+		span.SetAttr("http.target", r.URL.RequestURI())
+		span.SetAttr("request_id", id)
+
+		ids := []any{
+			slog.String("request_id", id),
+			slog.String("trace_id", span.Context.TraceID.String()),
+		}
+		ctx = trace.ContextWithRequestID(ctx, id)
+		ctx = trace.ContextWithLogger(ctx, app.log.With(ids...))
+
+		w.Header().Set(requestIDHeader, id)
+		w.Header().Set(trace.Header, span.Context.Traceparent())
+
+		sw := &statusWriter{ResponseWriter: w}
+		next.ServeHTTP(sw, r.WithContext(ctx))
+
+		status := sw.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		span.SetAttr("http.status_code", strconv.Itoa(status))
+		if status >= http.StatusInternalServerError {
+			span.SetError(errorStatus(status))
+		}
+
+		access.Info("Request served", append(ids,
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.RequestURI()),
+			slog.String("route", route),
+			slog.Int("status", status),
+			slog.Duration("duration", time.Since(start)),
+		)...)
+	})
+}
+
+// logger returns the request-scoped logger of the context, or the application's
+// logger outside of requests.
+func (app *app) logger(ctx context.Context) *slog.Logger {
+	return trace.Logger(ctx, app.log)
+}
+
+// tracingTransport propagates the trace of the request's context to upstream
+// servers and logs the requests made with next.
+func tracingTransport(next http.RoundTripper) http.RoundTripper {
+	return roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		ctx, span := trace.Start(r.Context(), r.Method+" "+r.URL.Host)
+		defer span.Finish()
+		span.SetAttr("http.url", r.URL.String())
+
+		if span != nil {
+			// A RoundTripper must not modify the request it is given.
+			r = r.Clone(ctx)
+			r.Header.Set(trace.Header, span.Context.Traceparent())
+		}
+
+		log := trace.Logger(ctx, nil)
+		start := time.Now()
+
+		res, err := next.RoundTrip(r)
+		if err != nil {
+			span.SetError(err)
+			if log != nil {
+				log.Warn("Upstream request failed",
+					slog.String("url", r.URL.String()),
+					slog.Duration("duration", time.Since(start)),
+					slog.String("error", err.Error()),
+				)
+			}
+			return res, err
+		}
+
+		span.SetAttr("http.status_code", strconv.Itoa(res.StatusCode))
+		if log != nil {
+			log.Debug("Upstream request",
+				slog.String("url", r.URL.String()),
+				slog.Int("status", res.StatusCode),
+				slog.Duration("duration", time.Since(start)),
+			)
+		}
+		return res, nil
+	})
+}
+
+// contextWriter carries the context of the request to the blogo plugins, whose
+// Render method only receives the writer of the response.
+type contextWriter struct {
+	http.ResponseWriter
+	ctx context.Context
+}
+
+func (w *contextWriter) Context() context.Context {
+	return w.ctx
+}
+
+// Unwrap allows [http.ResponseController] to access the underlying writer.
+func (w *contextWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// writerContext returns the context carried by w, if it is a [contextWriter],
+// or else a background context. Blogo may write to a buffer instead of the
+// response, in which case the request-scoped logger and span are not available.
+func writerContext(w io.Writer) context.Context {
+	if cw, ok := w.(interface{ Context() context.Context }); ok {
+		return cw.Context()
+	}
+	return context.Background()
+}
+
+// validRequestID reports if a request ID set by a client or proxy is safe to
+// be logged and returned.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '-' || c == '_' || c == '.' || c == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+type errorStatus int
+
+func (s errorStatus) Error() string {
+	return strconv.Itoa(int(s)) + " " + http.StatusText(int(s))
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"capytal.cc/internals/trace"
+)
+
+func TestTracedRequestID(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		reused bool
+	}{
+		{"missing", "", false},
+		{"valid", "req-42_a.b:c", true},
+		{"uuid", "7d444840-9dc0-11d1-b245-5ffdce74fad2", true},
+		{"spaces", "req 42", false},
+		{"newline", "req\n42", false},
+		{"not ascii", "requête", false},
+		{"too long", strings.Repeat("a", 129), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exp := &trace.MemoryExporter{}
+			app := &app{
+				log:    slog.New(slog.DiscardHandler),
+				tracer: trace.NewTracer(exp),
+			}
+
+			var ctxID string
+			h := app.traced("/test", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				ctxID = trace.RequestID(r.Context())
+			}))
+
+			r := httptest.NewRequest(http.MethodGet, "/test", nil)
+			if tt.header != "" {
+				r.Header.Set(requestIDHeader, tt.header)
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+
+			id := w.Header().Get(requestIDHeader)
+			if tt.reused && id != tt.header {
+				t.Errorf("request ID = %q, want %q", id, tt.header)
+			}
+			if !tt.reused && (id == tt.header || !validRequestID(id)) {
+				t.Errorf("request ID = %q, want a new ID", id)
+			}
+			if ctxID != id {
+				t.Errorf("request ID of the context = %q, want %q", ctxID, id)
+			}
+
+			spans := exp.Spans()
+			if len(spans) != 1 {
+				t.Fatalf("exported %d spans, want 1", len(spans))
+			}
+			if got := spans[0].Attrs["request_id"]; got != id {
+				t.Errorf("request ID of the span = %q, want %q", got, id)
+			}
+		})
+	}
+}
+
+func TestTracedTraceparent(t *testing.T) {
+	exp := &trace.MemoryExporter{}
+	app := &app{
+		log:    slog.New(slog.DiscardHandler),
+		tracer: trace.NewTracer(exp),
+	}
+	h := app.traced("/test", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+
+	parent := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	r := httptest.NewRequest(http.MethodGet, "/test", nil)
+	r.Header.Set(trace.Header, parent)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	spans := exp.Spans()
+	if len(spans) != 1 {
+		t.Fatalf("exported %d spans, want 1", len(spans))
+	}
+	s := spans[0]
+	if s.Context.TraceID.String() != "4bf92f3577b34da6a3ce929d0e0e4736" || s.ParentID.String() != "00f067aa0ba902b7" {
+		t.Errorf("span does not continue the trace of %s: %s", parent, s.Context.Traceparent())
+	}
+	if got := w.Header().Get(trace.Header); got != s.Context.Traceparent() {
+		t.Errorf("traceparent of the response = %q, want %q", got, s.Context.Traceparent())
+	}
+	if s.Attrs["http.status_code"] != "502" || s.Err == nil {
+		t.Errorf("span of a 502 response has status %q and error %v", s.Attrs["http.status_code"], s.Err)
+	}
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"net/url"
+	"strings"
+
+	"capytal.cc/internals/posts"
+	"capytal.cc/internals/seo"
+)
+
+// translation is a version of a blog post in one of the blogs' languages.
+type translation struct {
+	Lang string
+	Post *posts.Post
+	// Href is the path of the translation, relative to the site's root.
+	Href string
+	// URL is the absolute URL of the translation.
+	URL string
+}
+
+// translations returns the published versions of the post in all blogs, matched
+// by their [posts.Post.Key], in the order the blogs are configured. The post
+// itself is included.
+func (app *app) translations(ctx context.Context, p *posts.Post) ([]translation, error) {
+	var l []translation
+	for _, b := range app.blogs {
+		ps, err := b.Posts(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range ps {
+			if t.Key() == p.Key() {
+				l = append(l, app.translation(b.lang, t))
+				break
+			}
+		}
+	}
+	return l, nil
+}
+
+func (app *app) translation(lang string, p *posts.Post) translation {
+	path := "/blog/" + url.PathEscape(p.Name)
+	return translation{
+		Lang: lang,
+		Post: p,
+		Href: path + "?lang=" + url.QueryEscape(lang),
+		URL:  app.pageURL(path, lang),
+	}
+}
+
+// translationAlternates returns the translations as alternates of a page. The
+// "x-default" alternate is the first translation, the one of the default blog
+// if it has one, without a language, so the reader's language is negotiated.
+func translationAlternates(ts []translation) []seo.Alternate {
+	if len(ts) == 0 {
+		return nil
+	}
+
+	alts := make([]seo.Alternate, 0, len(ts)+1)
+	for _, t := range ts {
+		alts = append(alts, seo.Alternate{Lang: t.Lang, URL: t.URL})
+	}
+	defaultURL, _, _ := strings.Cut(ts[0].URL, "?")
+	return append(alts, seo.Alternate{Lang: "x-default", URL: defaultURL})
+}
+
+// translationFallback finds the post with the name in the blogs other than b. If
+// the post has a translation in b, it is returned as the redirect target, else
+// the post is returned with its blog to be served in place of the missing
+// translation. Both are empty if no other blog has the post.
+func (app *app) translationFallback(ctx context.Context, b *blog, name string) (redirect string, from *blog, post *posts.Post, err error) {
+	for _, o := range app.blogs {
+		if o == b {
+			continue
+		}
+
+		p, err := o.Post(ctx, name)
+		if err != nil {
+			return "", nil, nil, err
+		}
+		if p == nil {
+			continue
+		}
+
+		ts, err := app.translations(ctx, p)
+		if err != nil {
+			return "", nil, nil, err
+		}
+		for _, t := range ts {
+			if t.Lang == b.lang {
+				return t.Href, nil, nil, nil
+			}
+		}
+
+		return "", o, p, nil
+	}
+	return "", nil, nil, nil
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"capytal.cc/locales"
+)
+
+// wellKnownDir is the directory in the assets file system where files served at
+// the root of the site and under "/.well-known/" are looked up.
+const wellKnownDir = "well-known"
+
+// aiCrawlers are the user agents of crawlers used to train AI models, which are
+// disallowed in the default robots.txt.
+var aiCrawlers = []string{
+	"GPTBot",
+	"ChatGPT-User",
+	"CCBot",
+	"ClaudeBot",
+	"anthropic-ai",
+	"Google-Extended",
+	"Applebot-Extended",
+	"Bytespider",
+	"PerplexityBot",
+	"meta-externalagent",
+}
+
+// wellKnown serves the file with the name, such as "robots.txt" or
+// "security.txt". Its content is, in order of precedence: the content set by
+// [WithWellKnownFile], the file with the same name in the "well-known" directory
+// of the assets, or a default generated by the application, if there is one.
+func (app *app) wellKnown(name string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ct := mime.TypeByExtension(path.Ext(name))
+		if ct == "" {
+			ct = "text/plain; charset=utf-8"
+		}
+
+		content, ok := app.wellKnownFiles[name]
+		if !ok {
+			c, err := fs.ReadFile(app.assets, path.Join(wellKnownDir, name))
+			if err != nil && !errors.Is(err, fs.ErrNotExist) {
+				app.serverError(w, r, err)
+				return
+			}
+			content, ok = string(c), err == nil
+		}
+		if !ok {
+			switch name {
+			case "robots.txt":
+				content, ok = app.robots(), true
+			case "security.txt":
+				c, err := app.securityTxt(r.Context())
+				if err != nil {
+					app.serverError(w, r, err)
+					return
+				}
+				content, ok = c, true
+			}
+		}
+		if !ok {
+			app.notFound(w, r)
+			return
+		}
+
+		w.Header().Set("Content-Type", ct)
+		_, _ = w.Write([]byte(content))
+	})
+}
+
+// wellKnownDirectory serves files under the "/.well-known/" path.
+func (app *app) wellKnownDirectory() http.Handler {
+	return http.StripPrefix("/.well-known/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := path.Clean(r.URL.Path)
+		if name == "." || strings.Contains(name, "/") {
+			app.notFound(w, r)
+			return
+		}
+		app.wellKnown(name).ServeHTTP(w, r)
+	}))
+}
+
+func (app *app) robots() string {
+	b := new(strings.Builder)
+
+	if app.dev {
+		b.WriteString("User-agent: *\nDisallow: /\n")
+		return b.String()
+	}
+
+	for _, ua := range aiCrawlers {
+		fmt.Fprintf(b, "User-agent: %s\n", ua)
+	}
+	b.WriteString("Disallow: /\n\n")
+
+	b.WriteString("User-agent: *\nAllow: /\nDisallow: /blog/search\nDisallow: /hooks/\nDisallow: /admin/\n\n")
+	fmt.Fprintf(b, "Sitemap: %s/sitemap.xml\n", app.baseURL)
+
+	return b.String()
+}
+
+func (app *app) securityTxt(ctx context.Context) (string, error) {
+	langs := locales.Registry().Languages()
+	tags := make([]string, len(langs))
+	for i, l := range langs {
+		tags[i], _, _ = strings.Cut(l.Tag, "-")
+	}
+
+	// RFC 9116 requires an expiration date, which is kept always one year ahead
+	// since the file is generated, or since the newest post if the dates must
+	// come from the sources, unless it is fixed.
+	expires := app.securityExpires
+	if expires.IsZero() && app.sourceDates {
+		newest, err := app.newestPost(ctx)
+		if err != nil {
+			return "", err
+		}
+		if !newest.IsZero() {
+			expires = newest.UTC().Truncate(24*time.Hour).AddDate(1, 0, 0)
+		}
+	}
+	if expires.IsZero() {
+		expires = time.Now().UTC().Truncate(24*time.Hour).AddDate(1, 0, 0)
+	}
+
+	return fmt.Sprintf(
+		"Contact: mailto:contact@capytal.cc\nExpires: %s\nPreferred-Languages: %s\nCanonical: %s/.well-known/security.txt\n",
+		expires.Format(time.RFC3339),
+		strings.Join(tags, ", "),
+		app.baseURL,
+	), nil
+}
+
+// newestPost returns the latest modification date of the published posts of all
+// blogs, or the zero time if there is none.
+func (app *app) newestPost(ctx context.Context) (time.Time, error) {
+	var newest time.Time
+	for _, b := range app.blogs {
+		ps, err := b.Posts(ctx)
+		if err != nil {
+			return time.Time{}, err
+		}
+		for _, p := range ps {
+			if p.Modified.After(newest) {
+				newest = p.Modified
+			}
+		}
+	}
+	return newest, nil
+}